@@ -1,10 +1,13 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"log"
 	"os"
+	"os/signal"
 	"strconv"
+	"syscall"
 
 	"github.com/korotovsky/slack-mcp-server/pkg/provider"
 	"github.com/korotovsky/slack-mcp-server/pkg/server"
@@ -19,27 +22,24 @@ func main() {
 	flag.StringVar(&transport, "transport", "stdio", "Transport type (stdio or sse)")
 	flag.Parse()
 
-	p := provider.New()
+	workspaces, err := provider.NewWorkspaces()
+	if err != nil {
+		log.Fatalf("Error configuring workspaces: %v", err)
+	}
 
 	s := server.NewMCPServer(
-		p,
+		workspaces,
 	)
 
-	go func() {
-		log.Println("Booting provider...")
-
-		if os.Getenv("SLACK_MCP_XOXC_TOKEN") == "demo" && os.Getenv("SLACK_MCP_XOXD_TOKEN") == "demo" {
-			log.Println("Demo credentials are set, skip.")
-			return
-		}
+	if os.Getenv("SLACK_MCP_XOXC_TOKEN") == "demo" && os.Getenv("SLACK_MCP_XOXD_TOKEN") == "demo" {
+		log.Println("Demo credentials are set, skip.")
+	} else {
+		go refreshOnSignal(workspaces)
 
-		_, err := p.Provide()
-		if err != nil {
-			log.Fatalf("Error booting provider: %v", err)
+		for _, p := range workspaces.All() {
+			go bootProvider(p)
 		}
-
-		log.Println("Provider booted successfully.")
-	}()
+	}
 
 	switch transport {
 	case "stdio":
@@ -67,3 +67,39 @@ func main() {
 		)
 	}
 }
+
+func bootProvider(p *provider.ApiProvider) {
+	log.Printf("Booting provider %s...", p.Name())
+
+	p.StartUsersRefresher(context.Background())
+
+	_, err := p.Provide()
+	if err != nil {
+		// Tool calls retry the boot and report the error to the client.
+		log.Printf("Error booting provider %s: %v", p.Name(), err)
+		return
+	}
+
+	log.Printf("Provider %s booted successfully.", p.Name())
+
+	p.StartChannelsRefresher(context.Background())
+}
+
+// refreshOnSignal forces a users and channels refresh of every workspace
+// whenever the process receives SIGHUP, e.g. after someone joined.
+func refreshOnSignal(workspaces *provider.Workspaces) {
+	sighup := make(chan os.Signal, 1)
+	signal.Notify(sighup, syscall.SIGHUP)
+
+	for range sighup {
+		log.Println("Received SIGHUP, refreshing users and channels...")
+		for _, p := range workspaces.All() {
+			if err := p.RefreshUsers(context.Background()); err != nil {
+				log.Printf("Users refresh of %s failed: %v", p.Name(), err)
+			}
+			if err := p.RefreshChannels(context.Background()); err != nil {
+				log.Printf("Channels refresh of %s failed: %v", p.Name(), err)
+			}
+		}
+	}
+}
@@ -2,43 +2,93 @@ package handler
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"sort"
+	"strconv"
 	"strings"
+	"time"
 
-	"github.com/gocarina/gocsv"
 	"github.com/korotovsky/slack-mcp-server/pkg/provider"
+	"github.com/korotovsky/slack-mcp-server/pkg/text"
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/slack-go/slack"
 )
 
+// maxMemberLookups is the number of members missing from the users cache
+// that channel_members fetches one by one per call. Any further members
+// are returned by ID only.
+const maxMemberLookups = 20
+
 var AllChanTypes = []string{"mpim", "im", "public_channel", "private_channel"}
 var PubChanType = "public_channel"
 
 type Channel struct {
 	ID          string `json:"id"`
 	Name        string `json:"name"`
+	Type        string `json:"type"`
 	Topic       string `json:"topic"`
 	Purpose     string `json:"purpose"`
 	MemberCount int    `json:"memberCount"`
+	IsMember    bool   `json:"isMember"`
 	Cursor      string `json:"cursor"`
 }
 
+type ChannelInfo struct {
+	ID           string `json:"id"`
+	Name         string `json:"name"`
+	Type         string `json:"type"`
+	CreatorID    string `json:"creatorID"`
+	CreatorName  string `json:"creatorName"`
+	Created      string `json:"created"`
+	IsArchived   bool   `json:"isArchived"`
+	IsPrivate    bool   `json:"isPrivate"`
+	IsShared     bool   `json:"isShared"`
+	IsExtShared  bool   `json:"isExtShared"`
+	IsMember     bool   `json:"isMember"`
+	MemberCount  int    `json:"memberCount"`
+	Topic        string `json:"topic"`
+	TopicSetBy   string `json:"topicSetBy"`
+	TopicSetAt   string `json:"topicSetAt"`
+	Purpose      string `json:"purpose"`
+	PurposeSetBy string `json:"purposeSetBy"`
+	PurposeSetAt string `json:"purposeSetAt"`
+	LastActivity string `json:"lastActivity"`
+}
+
+// TopicChange is a change of a channel's topic or purpose found in its
+// history.
+type TopicChange struct {
+	Time     string `json:"time"`
+	Field    string `json:"field"`
+	Value    string `json:"value"`
+	UserID   string `json:"userID"`
+	UserName string `json:"userName"`
+}
+
 type ChannelsHandler struct {
-	apiProvider *provider.ApiProvider
+	workspaces  *provider.Workspaces
 	validTypes  map[string]bool
+	textOptions text.Options
+	location    *time.Location
+	budget      historyBudget
+	format      Format
 }
 
-func NewChannelsHandler(apiProvider *provider.ApiProvider) *ChannelsHandler {
+func NewChannelsHandler(workspaces *provider.Workspaces) *ChannelsHandler {
 	validTypes := make(map[string]bool, len(AllChanTypes))
 	for _, v := range AllChanTypes {
 		validTypes[v] = true
 	}
 
 	return &ChannelsHandler{
-		apiProvider: apiProvider,
+		workspaces:  workspaces,
 		validTypes:  validTypes,
+		textOptions: textOptionsFromEnv(),
+		location:    locationFromEnv(),
+		budget:      historyBudgetFromEnv(),
+		format:      formatFromEnv(),
 	}
 }
 
@@ -65,75 +115,518 @@ func (ch *ChannelsHandler) ChannelsHandler(ctx context.Context, request mcp.Call
 		limit = 100
 	}
 
-	api, err := ch.apiProvider.Provide()
+	format, err := requestFormat(request, ch.format)
 	if err != nil {
 		return nil, err
 	}
 
-	var channelList []Channel
+	apiProvider, err := ch.workspaces.Provider(request.GetString("workspace", ""))
+	if err != nil {
+		return nil, err
+	}
 
-	params := &slack.GetConversationsParameters{
-		Types:           channelTypes,
-		Limit:           limit,
-		ExcludeArchived: true,
-		Cursor:          cursor,
+	api, err := apiProvider.Provide()
+	if err != nil {
+		return nil, err
 	}
+
 	var (
-		total   int
-		nextcur string
+		channelList []Channel
+		nextcur     string
+		fetchErr    error
 	)
-	for {
-		var chans []slack.Channel
+	if channels, offset, ok := ch.directoryChannels(ctx, apiProvider, channelTypes, cursor); ok {
+		// The directory is sorted here and paginated by offset, so that
+		// pages of a popularity sorted listing do not overlap.
+		sortChannels(channels, sortType)
+		if offset > len(channels) {
+			offset = len(channels)
+		}
+		channelList = channels[offset:]
+		if len(channelList) > limit {
+			channelList = channelList[:limit]
+			nextcur = fmt.Sprintf("offset:%d", offset+limit)
+		}
+	} else {
+		params := &slack.GetConversationsParameters{
+			Types:           channelTypes,
+			Limit:           limit,
+			ExcludeArchived: true,
+			Cursor:          cursor,
+		}
+		channelList, nextcur, fetchErr = fetchChannels(ctx, api, params, limit)
+		if fetchErr != nil {
+			if len(channelList) == 0 {
+				return nil, fmt.Errorf("failed to list channels: %w", fetchErr)
+			}
+			log.Printf("channels fetch failed after %d channels: %v", len(channelList), fetchErr)
+		}
+		sortChannels(channelList, sortType)
+	}
+
+	if len(channelList) > 0 && nextcur != "" {
+		channelList[len(channelList)-1].Cursor = nextcur
+	}
+
+	result, err := rowsResult(channelList, format)
+	if err != nil {
+		return nil, err
+	}
+	if fetchErr != nil {
+		// Keep the list intact and report the failure next to it, so that
+		// the caller knows the list is incomplete and where to resume.
+		result.Content = append(result.Content, mcp.NewTextContent(fmt.Sprintf(
+			"Partial result: listing channels failed after %d channels: %v. Call again with cursor %q to resume.",
+			len(channelList), fetchErr, nextcur,
+		)))
+	}
+
+	return result, nil
+}
+
+// ChannelInfoHandler returns the details of a single channel, optionally
+// followed by its pinned items and bookmarks so that a model gets the
+// channel's canonical references before reading its history, and by the
+// earlier topic and purpose changes found in the history.
+func (ch *ChannelsHandler) ChannelInfoHandler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	channel := request.GetString("channel_id", "")
+	if channel == "" {
+		return nil, errors.New("channel_id must be a string")
+	}
+
+	includePins := request.GetBool("include_pins", false)
+	includeBookmarks := request.GetBool("include_bookmarks", false)
+	includeTopicHistory := request.GetBool("include_topic_history", false)
+
+	format, err := requestFormat(request, ch.format)
+	if err != nil {
+		return nil, err
+	}
+
+	apiProvider, err := ch.workspaces.Provider(request.GetString("workspace", ""))
+	if err != nil {
+		return nil, err
+	}
+
+	api, err := apiProvider.Provide()
+	if err != nil {
+		return nil, err
+	}
+
+	channel, err = apiProvider.ResolveChannel(ctx, channel)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := api.GetConversationInfoContext(ctx, &slack.GetConversationInfoInput{
+		ChannelID:         channel,
+		IncludeNumMembers: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	row := toChannelInfo(*info, apiProvider.ProvideUsersMap())
+	if row.LastActivity == "" {
+		row.LastActivity = lastActivity(ctx, api, channel)
+	}
+
+	result, err := rowsResult([]ChannelInfo{row}, format)
+	if err != nil {
+		return nil, err
+	}
+
+	if includePins {
+		pins, err := fetchPins(ctx, api, apiProvider, channel, ch.textOptions)
+		if err != nil {
+			result.Content = append(result.Content, mcp.NewTextContent("Pinned items not available: "+apiProvider.DescribeError(err).Error()))
+		} else {
+			section, err := messagesResult(pins, format, ch.location)
+			if err != nil {
+				return nil, err
+			}
+			appendSection(result, format, "pins", "Pinned items:", section)
+		}
+	}
 
-		chans, nextcur, err = api.GetConversationsContext(ctx, params)
+	if includeBookmarks {
+		bookmarks, err := fetchBookmarks(ctx, api, apiProvider, channel)
 		if err != nil {
-			break
-		}
-
-		if l := len(chans); l > 0 {
-			for _, channel := range chans {
-				channelList = append(channelList, Channel{
-					ID:          channel.ID,
-					Name:        "#" + channel.Name,
-					Topic:       channel.Topic.Value,
-					Purpose:     channel.Purpose.Value,
-					MemberCount: channel.NumMembers,
-				})
+			result.Content = append(result.Content, mcp.NewTextContent("Bookmarks not available: "+apiProvider.DescribeError(err).Error()))
+		} else {
+			section, err := rowsResult(bookmarks, format)
+			if err != nil {
+				return nil, err
 			}
+			appendSection(result, format, "bookmarks", "Bookmarks:", section)
+		}
+	}
 
-			total += l
-			params.Limit -= l
+	if includeTopicHistory {
+		changes, complete, err := fetchTopicHistory(ctx, api, channel, apiProvider.ProvideUsersMap(), ch.budget.maxMessages)
+		if err != nil {
+			result.Content = append(result.Content, mcp.NewTextContent("Topic and purpose history not available: "+apiProvider.DescribeError(err).Error()))
+		} else {
+			section, err := rowsResult(changes, format)
+			if err != nil {
+				return nil, err
+			}
+			appendSection(result, format, "topic_history", "Topic and purpose history:", section)
+			if !complete {
+				result.Content = append(result.Content, mcp.NewTextContent(fmt.Sprintf(
+					"Topic and purpose history only covers the latest %d messages, the limit set by SLACK_MCP_HISTORY_MAX_MESSAGES.",
+					ch.budget.maxMessages)))
+			}
 		}
+	}
+
+	return result, nil
+}
+
+// ChannelMembersHandler lists the members of a channel with their names
+// and titles from the users cache. Pages of conversations.members are
+// fetched until limit members are collected. Up to maxMemberLookups
+// members missing from the cache are fetched with users.info; the others
+// are returned by ID while the cache is refreshed in the background.
+func (ch *ChannelsHandler) ChannelMembersHandler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	channel := request.GetString("channel_id", "")
+	if channel == "" {
+		return nil, errors.New("channel_id must be a string")
+	}
+
+	limit := request.GetInt("limit", 100)
+	if limit <= 0 || limit > 1000 {
+		return nil, fmt.Errorf("invalid limit %d: must be between 1 and 1000", limit)
+	}
+
+	format, err := requestFormat(request, ch.format)
+	if err != nil {
+		return nil, err
+	}
+
+	apiProvider, err := ch.workspaces.Provider(request.GetString("workspace", ""))
+	if err != nil {
+		return nil, err
+	}
+
+	api, err := apiProvider.Provide()
+	if err != nil {
+		return nil, err
+	}
+
+	channel, err = apiProvider.ResolveChannel(ctx, channel)
+	if err != nil {
+		return nil, err
+	}
+
+	ids, nextcur, err := fetchMembers(ctx, api, channel, request.GetString("cursor", ""), limit)
+	if err != nil {
+		return nil, err
+	}
+
+	usersMap := apiProvider.ProvideUsersMap()
+	userList := make([]User, 0, len(ids))
+	lookups, unresolved := 0, 0
+	for _, id := range ids {
+		user, ok := usersMap[id]
+		if !ok && lookups < maxMemberLookups {
+			lookups++
+			user, err = apiProvider.ProvideUser(ctx, id)
+			ok = err == nil
+			if err != nil {
+				log.Printf("Failed to resolve user %s: %v", id, err)
+			}
+		}
+		if !ok {
+			unresolved++
+			userList = append(userList, User{UserID: id})
+			continue
+		}
+		userList = append(userList, toUser(user))
+	}
+	if lookups == maxMemberLookups && unresolved > 0 {
+		// Many unknown members mean the users cache is stale: refresh it
+		// once rather than calling users.info for each of them.
+		log.Printf("%d members of %s are not in the users cache, refreshing users", unresolved, channel)
+		apiProvider.RequestUsersRefresh()
+	}
+
+	if len(userList) > 0 {
+		userList[len(userList)-1].Cursor = nextcur
+	}
+
+	return rowsResult(userList, format)
+}
+
+// fetchChannels pages through conversations.list until limit channels were
+// fetched or the list is exhausted. It returns the cursor of the next page.
+// When a page fails, the channels fetched so far are returned together with
+// the cursor of the failed page and the error, so that the listing can be
+// resumed.
+func fetchChannels(ctx context.Context, api *slack.Client, params *slack.GetConversationsParameters, limit int) ([]Channel, string, error) {
+	var (
+		channelList []Channel
+		total       int
+	)
+	for {
+		chans, nextcur, err := api.GetConversationsContext(ctx, params)
+		if err != nil {
+			return channelList, params.Cursor, err
+		}
+
+		for _, channel := range chans {
+			channelList = append(channelList, toChannel(channel, "#"+channel.Name))
+		}
+
+		total += len(chans)
+		params.Limit -= len(chans)
 
 		if total >= limit {
 			log.Printf("channels fetch limit reached %v", total)
-			break
+			return channelList, nextcur, nil
 		}
 
 		if nextcur == "" {
 			log.Printf("channels fetch exhausted")
-			break
+			return channelList, "", nil
 		}
 		params.Cursor = nextcur
 	}
+}
+
+// directoryChannels returns the channels of the given types from the
+// provider's channel directory, together with the offset encoded in cursor.
+// It reports false when the directory is not available or the cursor was
+// issued by the Slack API, in which case the API is queried instead.
+func (ch *ChannelsHandler) directoryChannels(ctx context.Context, apiProvider *provider.ApiProvider, channelTypes []string, cursor string) ([]Channel, int, bool) {
+	offset := 0
+	if cursor != "" {
+		value, ok := strings.CutPrefix(cursor, "offset:")
+		if !ok {
+			return nil, 0, false
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			return nil, 0, false
+		}
+		offset = n
+	}
 
+	channels, err := apiProvider.ProvideChannels(ctx)
+	if err != nil {
+		log.Printf("channel directory is not available, querying the API: %v", err)
+		return nil, 0, false
+	}
+
+	wanted := make(map[string]bool, len(channelTypes))
+	for _, t := range channelTypes {
+		wanted[t] = true
+	}
+
+	usersMap := apiProvider.ProvideUsersMap()
+
+	var channelList []Channel
+	for _, channel := range channels {
+		if channel.IsArchived || !wanted[provider.ChannelType(channel)] {
+			continue
+		}
+
+		channelList = append(channelList, toChannel(channel, channelName(channel, usersMap)))
+	}
+
+	return channelList, offset, true
+}
+
+// channelName returns "#name" for channels and "@user" for direct messages.
+func channelName(channel slack.Channel, usersMap map[string]slack.User) string {
+	if !channel.IsIM {
+		return "#" + channel.Name
+	}
+	if user, ok := usersMap[channel.User]; ok {
+		return "@" + user.Name
+	}
+	return "@" + channel.User
+}
+
+func toChannel(channel slack.Channel, name string) Channel {
+	return Channel{
+		ID:          channel.ID,
+		Name:        name,
+		Type:        provider.ChannelType(channel),
+		Topic:       channel.Topic.Value,
+		Purpose:     channel.Purpose.Value,
+		MemberCount: channel.NumMembers,
+		IsMember:    channel.IsMember,
+	}
+}
+
+func sortChannels(channelList []Channel, sortType string) {
 	switch sortType {
 	case "popularity":
-		sort.Slice(channelList, func(i, j int) bool {
-			return channelList[i].MemberCount > channelList[j].MemberCount
+		sort.SliceStable(channelList, func(i, j int) bool {
+			if channelList[i].MemberCount != channelList[j].MemberCount {
+				return channelList[i].MemberCount > channelList[j].MemberCount
+			}
+			return channelList[i].Name < channelList[j].Name
 		})
 	default:
-		// pass
+		sort.SliceStable(channelList, func(i, j int) bool {
+			return channelList[i].Name < channelList[j].Name
+		})
 	}
+}
 
-	if len(channelList) > 0 && nextcur != "" {
-		channelList[len(channelList)-1].Cursor = nextcur
+// fetchMembers pages through conversations.members until limit member IDs
+// are collected, and returns the cursor of the next page.
+func fetchMembers(ctx context.Context, api *slack.Client, channel, cursor string, limit int) ([]string, string, error) {
+	var ids []string
+	for {
+		page, next, err := api.GetUsersInConversationContext(ctx, &slack.GetUsersInConversationParameters{
+			ChannelID: channel,
+			Cursor:    cursor,
+			Limit:     limit - len(ids),
+		})
+		if err != nil {
+			return nil, "", err
+		}
+
+		ids = append(ids, page...)
+		cursor = next
+		if cursor == "" || len(ids) >= limit {
+			return ids, cursor, nil
+		}
 	}
+}
 
-	csvBytes, err := gocsv.MarshalBytes(&channelList)
+// lastActivity returns the timestamp of the newest message of a channel,
+// or an empty string if the history cannot be read.
+func lastActivity(ctx context.Context, api *slack.Client, channel string) string {
+	history, err := api.GetConversationHistoryContext(ctx, &slack.GetConversationHistoryParameters{
+		ChannelID: channel,
+		Limit:     1,
+	})
 	if err != nil {
-		return nil, err
+		log.Printf("Failed to get the last activity of %s: %v", channel, err)
+		return ""
 	}
+	if len(history.Messages) == 0 {
+		return ""
+	}
+
+	return history.Messages[0].Timestamp
+}
 
-	return mcp.NewToolResultText(string(csvBytes)), nil
+// fetchTopicHistory scans the history of a channel, newest first, for
+// messages that changed its topic or purpose. At most maxMessages
+// messages are scanned; complete reports whether the whole history was.
+func fetchTopicHistory(ctx context.Context, api *slack.Client, channel string, usersMap map[string]slack.User, maxMessages int) ([]TopicChange, bool, error) {
+	params := &slack.GetConversationHistoryParameters{ChannelID: channel}
+
+	var (
+		changes []TopicChange
+		scanned int
+	)
+	for {
+		params.Limit = min(historyPageSize, maxMessages-scanned)
+		history, err := api.GetConversationHistoryContext(ctx, params)
+		if err != nil {
+			return nil, false, err
+		}
+
+		for _, message := range history.Messages {
+			change := TopicChange{Time: message.Timestamp, UserID: message.User}
+			switch message.SubType {
+			case slack.MsgSubTypeChannelTopic, slack.MsgSubTypeGroupTopic:
+				change.Field, change.Value = "topic", message.Topic
+			case slack.MsgSubTypeChannelPurpose, slack.MsgSubTypeGroupPurpose:
+				change.Field, change.Value = "purpose", message.Purpose
+			default:
+				continue
+			}
+			if user, ok := usersMap[message.User]; ok {
+				change.UserName = user.Name
+			}
+			changes = append(changes, change)
+		}
+
+		scanned += len(history.Messages)
+		if !history.HasMore || history.ResponseMetaData.NextCursor == "" {
+			return changes, true, nil
+		}
+		if scanned >= maxMessages {
+			return changes, false, nil
+		}
+		params.Cursor = history.ResponseMetaData.NextCursor
+	}
+}
+
+// toChannelInfo converts the conversations.info response. Slack only
+// reports the latest topic and purpose change; fetchTopicHistory finds
+// the earlier ones.
+func toChannelInfo(channel slack.Channel, usersMap map[string]slack.User) ChannelInfo {
+	userName := func(id string) string {
+		if user, ok := usersMap[id]; ok {
+			return user.Name
+		}
+		return id
+	}
+	setAt := func(t slack.JSONTime) string {
+		if t == 0 {
+			return ""
+		}
+		return formatTs(t.Time())
+	}
+
+	info := ChannelInfo{
+		ID:           channel.ID,
+		Name:         channelName(channel, usersMap),
+		Type:         provider.ChannelType(channel),
+		CreatorID:    channel.Creator,
+		Created:      setAt(channel.Created),
+		IsArchived:   channel.IsArchived,
+		IsPrivate:    channel.IsPrivate || channel.IsGroup,
+		IsShared:     channel.IsShared,
+		IsExtShared:  channel.IsExtShared,
+		IsMember:     channel.IsMember,
+		MemberCount:  channel.NumMembers,
+		Topic:        channel.Topic.Value,
+		TopicSetAt:   setAt(channel.Topic.LastSet),
+		Purpose:      channel.Purpose.Value,
+		PurposeSetAt: setAt(channel.Purpose.LastSet),
+	}
+	if channel.Creator != "" {
+		info.CreatorName = userName(channel.Creator)
+	}
+	if channel.Topic.Creator != "" {
+		info.TopicSetBy = userName(channel.Topic.Creator)
+	}
+	if channel.Purpose.Creator != "" {
+		info.PurposeSetBy = userName(channel.Purpose.Creator)
+	}
+	if channel.Latest != nil {
+		info.LastActivity = channel.Latest.Timestamp
+	}
+
+	return info
+}
+
+// appendSection adds the rows of section to the structured content of
+// result under key. The CSV and Markdown formats also get the text of
+// section under a title; the JSON formats keep a single JSON document in
+// the text content.
+func appendSection(result *mcp.CallToolResult, format Format, key, title string, section *mcp.CallToolResult) {
+	if structured, ok := result.StructuredContent.(map[string]any); ok {
+		if rows, ok := section.StructuredContent.(map[string]any); ok {
+			structured[key] = rows["rows"]
+		}
+	}
+
+	if format == FormatJSON || format == FormatJSONL {
+		return
+	}
+	for _, content := range section.Content {
+		if tc, ok := content.(mcp.TextContent); ok {
+			result.Content = append(result.Content, mcp.NewTextContent(title+"\n"+tc.Text))
+		}
+	}
 }
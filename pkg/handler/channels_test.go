@@ -0,0 +1,321 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+
+	"github.com/slack-go/slack"
+)
+
+// fakeConversations serves conversations.list in pages of pageSize from
+// channels, using the offset as cursor. The page starting at failAt fails.
+type fakeConversations struct {
+	channels int
+	pageSize int
+	failAt   int
+	calls    int
+}
+
+func (f *fakeConversations) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.calls++
+	offset, _ := strconv.Atoi(r.FormValue("cursor"))
+	limit, _ := strconv.Atoi(r.FormValue("limit"))
+
+	w.Header().Set("Content-Type", "application/json")
+	if f.failAt >= 0 && offset == f.failAt {
+		json.NewEncoder(w).Encode(map[string]any{"ok": false, "error": "internal_error"})
+		return
+	}
+
+	size := min(f.pageSize, limit)
+	var page []map[string]any
+	for i := offset; i < f.channels && i < offset+size; i++ {
+		page = append(page, map[string]any{"id": fmt.Sprintf("C%03d", i), "name": fmt.Sprintf("chan%d", i), "num_members": i})
+	}
+	next := ""
+	if offset+len(page) < f.channels {
+		next = strconv.Itoa(offset + len(page))
+	}
+
+	json.NewEncoder(w).Encode(map[string]any{
+		"ok":                true,
+		"channels":          page,
+		"response_metadata": map[string]string{"next_cursor": next},
+	})
+}
+
+func TestFetchChannels(t *testing.T) {
+	tests := []struct {
+		name       string
+		failAt     int
+		cursor     string
+		limit      int
+		wantCount  int
+		wantCursor string
+		wantErr    bool
+	}{
+		{name: "Exhausted", failAt: -1, limit: 100, wantCount: 7},
+		{name: "Limit reached", failAt: -1, limit: 4, wantCount: 4, wantCursor: "4"},
+		{name: "Resume from cursor", failAt: -1, cursor: "3", limit: 100, wantCount: 4},
+		{name: "Failure mid-way", failAt: 6, limit: 100, wantCount: 6, wantCursor: "6", wantErr: true},
+		{name: "Failure on first page", failAt: 0, limit: 100, wantCount: 0, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &fakeConversations{channels: 7, pageSize: 3, failAt: tt.failAt}
+			srv := httptest.NewServer(fake)
+			defer srv.Close()
+
+			api := slack.New("xoxb-test", slack.OptionAPIURL(srv.URL+"/"))
+			params := &slack.GetConversationsParameters{Limit: tt.limit, Cursor: tt.cursor}
+
+			channels, cursor, err := fetchChannels(context.Background(), api, params, tt.limit)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(channels) != tt.wantCount {
+				t.Errorf("got %d channels, want %d", len(channels), tt.wantCount)
+			}
+			if cursor != tt.wantCursor {
+				t.Errorf("cursor = %q, want %q", cursor, tt.wantCursor)
+			}
+		})
+	}
+}
+
+func TestFetchMembers(t *testing.T) {
+	// Pages of at most three of seven members, using the offset as cursor.
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		offset, _ := strconv.Atoi(r.FormValue("cursor"))
+		limit, _ := strconv.Atoi(r.FormValue("limit"))
+
+		var page []string
+		for i := offset; i < 7 && i < offset+min(3, limit); i++ {
+			page = append(page, fmt.Sprintf("U%03d", i))
+		}
+		next := ""
+		if offset+len(page) < 7 {
+			next = strconv.Itoa(offset + len(page))
+		}
+
+		json.NewEncoder(w).Encode(map[string]any{
+			"ok":                true,
+			"members":           page,
+			"response_metadata": map[string]string{"next_cursor": next},
+		})
+	}))
+	defer srv.Close()
+	api := slack.New("xoxb-test", slack.OptionAPIURL(srv.URL+"/"))
+
+	tests := []struct {
+		name       string
+		cursor     string
+		limit      int
+		wantCount  int
+		wantCursor string
+	}{
+		{name: "Exhausted", limit: 100, wantCount: 7},
+		{name: "Limit reached", limit: 4, wantCount: 4, wantCursor: "4"},
+		{name: "Resume from cursor", cursor: "4", limit: 100, wantCount: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ids, cursor, err := fetchMembers(context.Background(), api, "C0123456789", tt.cursor, tt.limit)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(ids) != tt.wantCount || cursor != tt.wantCursor {
+				t.Errorf("fetchMembers() = %d members, cursor %q; want %d, %q", len(ids), cursor, tt.wantCount, tt.wantCursor)
+			}
+		})
+	}
+}
+
+func TestFetchTopicHistory(t *testing.T) {
+	// Two pages of history, newest first, using the page number as cursor.
+	pages := [][]map[string]any{
+		{
+			{"type": "message", "ts": "1700000400.000000", "user": "U1", "text": "hello"},
+			{"type": "message", "subtype": "channel_topic", "ts": "1700000300.000000", "user": "U1", "topic": "Deploys"},
+		},
+		{
+			{"type": "message", "subtype": "channel_purpose", "ts": "1700000200.000000", "user": "U2", "purpose": "Release coordination"},
+			{"type": "message", "subtype": "channel_topic", "ts": "1700000100.000000", "user": "U2", "topic": "Builds"},
+		},
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page, _ := strconv.Atoi(r.FormValue("cursor"))
+		next := ""
+		if page+1 < len(pages) {
+			next = strconv.Itoa(page + 1)
+		}
+
+		json.NewEncoder(w).Encode(map[string]any{
+			"ok":                true,
+			"messages":          pages[page],
+			"has_more":          next != "",
+			"response_metadata": map[string]string{"next_cursor": next},
+		})
+	}))
+	defer srv.Close()
+	api := slack.New("xoxb-test", slack.OptionAPIURL(srv.URL+"/"))
+	usersMap := map[string]slack.User{"U1": {ID: "U1", Name: "alice"}}
+
+	changes, complete, err := fetchTopicHistory(context.Background(), api, "C0123456789", usersMap, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []TopicChange{
+		{Time: "1700000300.000000", Field: "topic", Value: "Deploys", UserID: "U1", UserName: "alice"},
+		{Time: "1700000200.000000", Field: "purpose", Value: "Release coordination", UserID: "U2"},
+		{Time: "1700000100.000000", Field: "topic", Value: "Builds", UserID: "U2"},
+	}
+	if !complete || fmt.Sprint(changes) != fmt.Sprint(want) {
+		t.Errorf("fetchTopicHistory() = %+v, %v; want %+v, true", changes, complete, want)
+	}
+
+	changes, complete, err = fetchTopicHistory(context.Background(), api, "C0123456789", usersMap, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if complete || len(changes) != 1 {
+		t.Errorf("fetchTopicHistory() with a budget of 2 = %+v, %v; want the first change, false", changes, complete)
+	}
+}
+
+func TestToChannelInfo(t *testing.T) {
+	usersMap := map[string]slack.User{"U1": {ID: "U1", Name: "alice"}}
+
+	var channel slack.Channel
+	channel.ID = "C1"
+	channel.Name = "ops"
+	channel.Creator = "U1"
+	channel.Created = 1700000000
+	channel.IsPrivate = true
+	channel.IsExtShared = true
+	channel.NumMembers = 12
+	channel.Topic = slack.Topic{Value: "Deploys", Creator: "U2", LastSet: 1700000100}
+	channel.Latest = &slack.Message{Msg: slack.Msg{Timestamp: "1700000200.000100"}}
+
+	want := ChannelInfo{
+		ID:           "C1",
+		Name:         "#ops",
+		Type:         "private_channel",
+		CreatorID:    "U1",
+		CreatorName:  "alice",
+		Created:      "1700000000.000000",
+		IsPrivate:    true,
+		IsExtShared:  true,
+		MemberCount:  12,
+		Topic:        "Deploys",
+		TopicSetBy:   "U2",
+		TopicSetAt:   "1700000100.000000",
+		LastActivity: "1700000200.000100",
+	}
+	if got := toChannelInfo(channel, usersMap); got != want {
+		t.Errorf("toChannelInfo() = %+v, want %+v", got, want)
+	}
+}
+
+func TestChannelMembersCapsLookups(t *testing.T) {
+	t.Setenv("SLACK_MCP_OUTPUT_FORMAT", "json")
+
+	var members []string
+	for i := 0; i < 30; i++ {
+		members = append(members, fmt.Sprintf("U%09d", i))
+	}
+	var lookups atomic.Int32
+	workspaces := newTestWorkspaces(t, nil, map[string]http.HandlerFunc{
+		"conversations.members": writeJSON(map[string]any{"ok": true, "members": members}),
+		"users.info": func(w http.ResponseWriter, r *http.Request) {
+			lookups.Add(1)
+			id := r.Form.Get("user")
+			writeJSON(map[string]any{"ok": true, "user": map[string]any{"id": id, "name": "name-" + id}})(w, r)
+		},
+	})
+	ch := NewChannelsHandler(workspaces)
+
+	result, err := ch.ChannelMembersHandler(context.Background(), callTool(map[string]any{
+		"channel_id": "C0123456789",
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var users []User
+	if err := json.Unmarshal([]byte(resultText(t, result)), &users); err != nil {
+		t.Fatal(err)
+	}
+	if got := lookups.Load(); got != maxMemberLookups {
+		t.Errorf("users.info called %d times, want %d", got, maxMemberLookups)
+	}
+	if len(users) != len(members) {
+		t.Fatalf("got %d members, want %d", len(users), len(members))
+	}
+	if users[0].UserName != "name-"+members[0] {
+		t.Errorf("first member = %+v, want it resolved", users[0])
+	}
+	if last := users[len(users)-1]; last.UserID != members[len(members)-1] || last.UserName != "" {
+		t.Errorf("last member = %+v, want its ID only", last)
+	}
+}
+
+func TestChannelInfoSections(t *testing.T) {
+	t.Setenv("SLACK_MCP_OUTPUT_FORMAT", "json")
+
+	workspaces := newTestWorkspaces(t, nil, map[string]http.HandlerFunc{
+		"pins.list": writeJSON(map[string]any{"ok": true, "items": []map[string]any{
+			{"type": "message", "channel": "C0123456789", "message": map[string]any{"type": "message", "ts": "1700000500.000000", "user": "U1", "text": "release checklist"}},
+		}}),
+		"bookmarks.list": writeJSON(map[string]any{"ok": true, "bookmarks": []map[string]any{
+			{"id": "Bk1", "title": "Runbook", "link": "https://example.com/runbook", "type": "link"},
+		}}),
+		"conversations.history": writeJSON(map[string]any{"ok": true, "messages": []map[string]any{
+			{"type": "message", "subtype": "channel_topic", "ts": "1700000300.000000", "user": "U1", "topic": "Deploys"},
+		}}),
+	})
+	ch := NewChannelsHandler(workspaces)
+	args := map[string]any{
+		"channel_id":            "C0123456789",
+		"include_pins":          true,
+		"include_bookmarks":     true,
+		"include_topic_history": true,
+	}
+
+	result, err := ch.ChannelInfoHandler(context.Background(), callTool(args))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Content) != 1 {
+		t.Errorf("got %d content blocks in JSON, want the channel only", len(result.Content))
+	}
+	var structured struct {
+		Rows         []ChannelInfo `json:"rows"`
+		Pins         []Message     `json:"pins"`
+		Bookmarks    []Bookmark    `json:"bookmarks"`
+		TopicHistory []TopicChange `json:"topic_history"`
+	}
+	raw, _ := json.Marshal(result.StructuredContent)
+	if err := json.Unmarshal(raw, &structured); err != nil {
+		t.Fatal(err)
+	}
+	if len(structured.Rows) != 1 || len(structured.Pins) != 1 || len(structured.Bookmarks) != 1 || len(structured.TopicHistory) != 1 {
+		t.Errorf("structured content = %s, want one row, pin, bookmark and topic change", raw)
+	}
+
+	args["format"] = "csv"
+	result, err = ch.ChannelInfoHandler(context.Background(), callTool(args))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Content) != 4 {
+		t.Errorf("got %d content blocks in CSV, want the channel and three sections", len(result.Content))
+	}
+}
@@ -4,45 +4,69 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"log"
+	"os"
 	"strconv"
 	"strings"
 	"time"
 
-	"github.com/gocarina/gocsv"
 	"github.com/korotovsky/slack-mcp-server/pkg/provider"
 	"github.com/korotovsky/slack-mcp-server/pkg/text"
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/slack-go/slack"
 )
 
+const (
+	// defaultHistoryLimit is the time range of channel history returned
+	// when none of limit, oldest, latest and cursor is given.
+	defaultHistoryLimit = "1d"
+	// defaultRepliesLimit is the number of thread messages returned when
+	// neither limit nor cursor is given.
+	defaultRepliesLimit = "100"
+)
+
 type Message struct {
-	UserID   string `json:"userID"`
-	UserName string `json:"userUser"`
-	RealName string `json:"realName"`
-	Channel  string `json:"channelID"`
-	Text     string `json:"text"`
-	Time     string `json:"time"`
-	Cursor   string `json:"cursor"`
+	UserID      string `json:"userID"`
+	UserName    string `json:"userUser"`
+	RealName    string `json:"realName"`
+	Channel     string `json:"channelID"`
+	ChannelName string `json:"channelName"`
+	ThreadTs    string `json:"threadTs"`
+	ReplyCount  int    `json:"replyCount"`
+	Text        string `json:"text"`
+	Time        string `json:"time"`
+	Reactions   string `json:"reactions"`
+	Files       string `json:"files"`
+	Permalink   string `json:"permalink"`
+	Cursor      string `json:"cursor"`
+
+	// ReactionItems and FileItems hold the values flattened into Reactions
+	// and Files, for the Markdown transcript.
+	ReactionItems []slack.ItemReaction `json:"-" csv:"-"`
+	FileItems     []slack.File         `json:"-" csv:"-"`
 }
 
 type ConversationsHandler struct {
-	apiProvider *provider.ApiProvider
+	workspaces  *provider.Workspaces
+	writePolicy *WritePolicy
+	textOptions text.Options
+	location    *time.Location
+	budget      historyBudget
+	format      Format
 }
 
-func NewConversationsHandler(apiProvider *provider.ApiProvider) *ConversationsHandler {
+func NewConversationsHandler(workspaces *provider.Workspaces) *ConversationsHandler {
 	return &ConversationsHandler{
-		apiProvider: apiProvider,
+		workspaces:  workspaces,
+		writePolicy: NewWritePolicyFromEnv(),
+		textOptions: textOptionsFromEnv(),
+		location:    locationFromEnv(),
+		budget:      historyBudgetFromEnv(),
+		format:      formatFromEnv(),
 	}
 }
 
 func (ch *ConversationsHandler) ConversationsHistoryHandler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	var (
-		err         error
-		paramLimit  int
-		paramOldest string
-		paramLatest string
-	)
-
 	channel := request.GetString("channel_id", "")
 	if channel == "" {
 		return nil, errors.New("channel_id must be a string")
@@ -50,68 +74,640 @@ func (ch *ConversationsHandler) ConversationsHistoryHandler(ctx context.Context,
 
 	limit := request.GetString("limit", "")
 	cursor := request.GetString("cursor", "")
+	oldest := request.GetString("oldest", "")
+	latest := request.GetString("latest", "")
+	now := time.Now().In(ch.location)
+	if limit == "" && cursor == "" && oldest == "" && latest == "" {
+		limit = defaultHistoryLimit
+	}
 
-	if strings.HasSuffix(limit, "d") {
-		paramLimit, paramOldest, paramLatest, err = limitByDays(limit)
+	var (
+		paramLimit               int
+		paramOldest, paramLatest string
+		rangeLimit               int
+		err                      error
+	)
+	rangeCursor, isRangeCursor := parseRangeCursor(cursor)
+	switch {
+	case isRangeCursor:
+		paramOldest, paramLatest, cursor = rangeCursor.oldest, rangeCursor.latest, ""
+		rangeLimit = rangeCursor.limit
+	case oldest != "" || latest != "":
+		paramLimit, paramOldest, paramLatest, err = parseRange(limit, oldest, latest, now)
+		if limit != "" {
+			rangeLimit = paramLimit
+		}
+	default:
+		paramLimit, paramOldest, paramLatest, err = parseLimit(limit, cursor, now)
+	}
+	if err != nil {
+		return nil, err
+	}
+	// Time ranges are fetched completely, up to the history budget or the
+	// number of messages given with the range; a number of messages alone
+	// is fetched as a single page.
+	timeRange := isRangeCursor || oldest != "" || latest != "" || strings.HasSuffix(limit, "d")
+
+	textOpts, err := ch.requestTextOptions(request)
+	if err != nil {
+		return nil, err
+	}
+
+	format, err := requestFormat(request, ch.format)
+	if err != nil {
+		return nil, err
+	}
+
+	apiProvider, err := ch.workspaces.Provider(request.GetString("workspace", ""))
+	if err != nil {
+		return nil, err
+	}
+
+	api, err := apiProvider.Provide()
+	if err != nil {
+		return nil, err
+	}
+
+	channel, err = apiProvider.ResolveChannel(ctx, channel)
+	if err != nil {
+		return nil, err
+	}
+
+	params := slack.GetConversationHistoryParameters{
+		ChannelID: channel,
+		Limit:     paramLimit,
+		Oldest:    paramOldest,
+		Latest:    paramLatest,
+		Cursor:    cursor,
+		Inclusive: false,
+	}
+
+	var messageList []Message
+	if timeRange {
+		messageList, err = ch.fetchHistoryRange(ctx, api, apiProvider, &params, textOpts, rangeLimit)
 		if err != nil {
 			return nil, err
 		}
-	} else if cursor == "" {
-		paramLimit, err = limitByNumeric(limit)
+	} else {
+		messages, err := api.GetConversationHistoryContext(ctx, &params)
 		if err != nil {
 			return nil, err
 		}
+
+		messageList = convertMessages(ctx, apiProvider, messages.Messages, channel, textOpts)
+
+		if len(messageList) > 0 && messages.HasMore {
+			messageList[len(messageList)-1].Cursor = messages.ResponseMetaData.NextCursor
+		}
+	}
+
+	return messagesResult(messageList, format, ch.location)
+}
+
+func (ch *ConversationsHandler) ConversationsRepliesHandler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	channel := request.GetString("channel_id", "")
+	if channel == "" {
+		return nil, errors.New("channel_id must be a string")
+	}
+
+	threadTs := request.GetString("thread_ts", "")
+	if threadTs == "" {
+		return nil, errors.New("thread_ts must be a string")
 	}
 
-	api, err := ch.apiProvider.Provide()
+	limit := request.GetString("limit", "")
+	cursor := request.GetString("cursor", "")
+	if limit == "" && cursor == "" {
+		// A thread is returned from its parent on, however old it is.
+		limit = defaultRepliesLimit
+	}
+
+	paramLimit, paramOldest, paramLatest, err := parseLimit(limit, cursor, time.Now().In(ch.location))
 	if err != nil {
 		return nil, err
 	}
 
-	params := slack.GetConversationHistoryParameters{
+	textOpts, err := ch.requestTextOptions(request)
+	if err != nil {
+		return nil, err
+	}
+
+	format, err := requestFormat(request, ch.format)
+	if err != nil {
+		return nil, err
+	}
+
+	apiProvider, err := ch.workspaces.Provider(request.GetString("workspace", ""))
+	if err != nil {
+		return nil, err
+	}
+
+	api, err := apiProvider.Provide()
+	if err != nil {
+		return nil, err
+	}
+
+	channel, err = apiProvider.ResolveChannel(ctx, channel)
+	if err != nil {
+		return nil, err
+	}
+
+	params := slack.GetConversationRepliesParameters{
 		ChannelID: channel,
+		Timestamp: threadTs,
 		Limit:     paramLimit,
 		Oldest:    paramOldest,
 		Latest:    paramLatest,
 		Cursor:    cursor,
-		Inclusive: false,
+		Inclusive: true,
+	}
+	messages, hasMore, nextCursor, err := api.GetConversationRepliesContext(ctx, &params)
+	if err != nil {
+		return nil, err
+	}
+
+	messageList := convertMessages(ctx, apiProvider, messages, channel, textOpts)
+
+	if len(messageList) > 0 && hasMore {
+		messageList[len(messageList)-1].Cursor = nextCursor
+	}
+
+	return messagesResult(messageList, format, ch.location)
+}
+
+func (ch *ConversationsHandler) ConversationsAddMessageHandler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	channel := request.GetString("channel_id", "")
+	if channel == "" {
+		return nil, errors.New("channel_id must be a string")
+	}
+
+	threadTs := request.GetString("thread_ts", "")
+	if threadTs != "" && !strings.Contains(threadTs, ".") {
+		return nil, errors.New("thread_ts must be a valid timestamp in format 1234567890.123456")
+	}
+
+	msgText := request.GetString("payload", "")
+	if strings.TrimSpace(msgText) == "" {
+		return nil, errors.New("payload must be a non-empty string")
+	}
+
+	contentType := request.GetString("content_type", "text/markdown")
+
+	options := []slack.MsgOption{}
+	switch contentType {
+	case "text/markdown":
+		options = append(options, slack.MsgOptionText(text.MarkdownToMrkdwn(msgText), false))
+	case "text/plain":
+		options = append(options, slack.MsgOptionText(msgText, false), slack.MsgOptionDisableMarkdown())
+	default:
+		return nil, errors.New("content_type must be either 'text/plain' or 'text/markdown'")
+	}
+	if threadTs != "" {
+		options = append(options, slack.MsgOptionTS(threadTs))
+	}
+
+	format, err := requestFormat(request, ch.format)
+	if err != nil {
+		return nil, err
+	}
+
+	apiProvider, err := ch.workspaces.Provider(request.GetString("workspace", ""))
+	if err != nil {
+		return nil, err
+	}
+
+	api, err := apiProvider.Provide()
+	if err != nil {
+		return nil, err
+	}
+
+	channel, err = ch.writePolicy.Resolve(ctx, apiProvider, channel)
+	if err != nil {
+		return nil, err
+	}
+
+	respChannel, respTimestamp, err := api.PostMessageContext(ctx, channel, options...)
+	if err != nil {
+		return nil, err
+	}
+
+	messageList := readBackMessage(ctx, apiProvider, api, Message{
+		Channel:  respChannel,
+		ThreadTs: threadTs,
+		Text:     text.ProcessTextWithOptions(msgText, ch.textOptions),
+		Time:     respTimestamp,
+	}, ch.textOptions)
+
+	return messagesResult(messageList, format, ch.location)
+}
+
+func (ch *ConversationsHandler) SearchMessagesHandler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	query, err := buildSearchQuery(searchFilters{
+		Query:       request.GetString("search_query", ""),
+		InChannel:   request.GetString("filter_in_channel", ""),
+		FromUser:    request.GetString("filter_users_from", ""),
+		Before:      request.GetString("filter_date_before", ""),
+		After:       request.GetString("filter_date_after", ""),
+		On:          request.GetString("filter_date_on", ""),
+		HasLink:     request.GetBool("filter_has_link", false),
+		HasReaction: request.GetBool("filter_has_reaction", false),
+		ThreadsOnly: request.GetBool("filter_threads_only", false),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	textOpts, err := ch.requestTextOptions(request)
+	if err != nil {
+		return nil, err
 	}
-	messages, err := api.GetConversationHistoryContext(ctx, &params)
+
+	params := slack.NewSearchParameters()
+
+	switch sortType := request.GetString("sort", "score"); sortType {
+	case "score", "timestamp":
+		params.Sort = sortType
+	default:
+		return nil, fmt.Errorf("invalid sort %q: allowed values are 'score' and 'timestamp'", sortType)
+	}
+
+	switch sortDir := request.GetString("sort_dir", "desc"); sortDir {
+	case "asc", "desc":
+		params.SortDirection = sortDir
+	default:
+		return nil, fmt.Errorf("invalid sort_dir %q: allowed values are 'asc' and 'desc'", sortDir)
+	}
+
+	limit := request.GetInt("limit", 20)
+	if limit <= 0 || limit > 100 {
+		return nil, fmt.Errorf("invalid limit %d: must be between 1 and 100", limit)
+	}
+	params.Count = limit
+
+	if cursor := request.GetString("cursor", ""); cursor != "" {
+		page, err := strconv.Atoi(strings.TrimPrefix(cursor, "page:"))
+		if err != nil || page <= 0 {
+			return nil, fmt.Errorf("invalid cursor %q", cursor)
+		}
+		params.Page = page
+	}
+
+	format, err := requestFormat(request, ch.format)
 	if err != nil {
 		return nil, err
 	}
 
-	usersMap := ch.apiProvider.ProvideUsersMap()
+	apiProvider, err := ch.workspaces.Provider(request.GetString("workspace", ""))
+	if err != nil {
+		return nil, err
+	}
+
+	api, err := apiProvider.Provide()
+	if err != nil {
+		return nil, err
+	}
+
+	messages, err := api.SearchMessagesContext(ctx, query, params)
+	if err != nil {
+		return nil, err
+	}
+
+	resolver := newMentionResolver(ctx, apiProvider)
+	authors := newAuthorResolver(ctx, apiProvider)
 
 	var messageList []Message
-	for _, message := range messages.Messages {
-		textTokenized := text.ProcessText(message.Text)
-		user, ok := usersMap[message.User]
-		if !ok {
-			// TODO: add periodic refetch of users
-			continue
+	for _, match := range messages.Matches {
+		userID, userName, realName := authors.resolve(match.User, "", match.Username, nil)
+		msg := Message{
+			UserID:      userID,
+			UserName:    userName,
+			RealName:    realName,
+			Channel:     match.Channel.ID,
+			ChannelName: "#" + match.Channel.Name,
+			ThreadTs:    threadTsFromPermalink(match.Permalink),
+			Text:        messageText(match.Text, resolver, textOpts),
+			Time:        match.Timestamp,
+			Permalink:   match.Permalink,
 		}
+		messageList = append(messageList, msg)
+	}
+
+	if len(messageList) > 0 && messages.Paging.Page < messages.Paging.Pages {
+		messageList[len(messageList)-1].Cursor = fmt.Sprintf("page:%d", messages.Paging.Page+1)
+	}
+
+	return messagesResult(messageList, format, ch.location)
+}
+
+// messageText rewrites the mentions in a message text and processes it
+// with opts. Raw mode returns the text exactly as received from Slack.
+func messageText(s string, resolver text.MentionResolver, opts text.Options) string {
+	if opts.Mode == text.ModeRaw {
+		return s
+	}
+
+	return text.ProcessTextWithOptions(text.ResolveMentions(s, resolver), opts)
+}
+
+func convertMessages(ctx context.Context, apiProvider *provider.ApiProvider, messages []slack.Message, channel string, textOpts text.Options) []Message {
+	resolver := newMentionResolver(ctx, apiProvider)
+	authors := newAuthorResolver(ctx, apiProvider)
+
+	channelName := ""
+	if name, ok := resolver.ChannelName(channel); ok {
+		channelName = "#" + name
+	}
+
+	usersMap := apiProvider.ProvideUsersMap()
+
+	var messageList []Message
+	for _, message := range messages {
+		textTokenized := messageText(message.Text, resolver, textOpts)
+		userID, userName, realName := authors.resolve(message.User, message.BotID, message.Username, message.BotProfile)
 
 		messageList = append(messageList, Message{
-			UserID:   message.User,
-			UserName: user.Name,
-			RealName: user.RealName,
-			Text:     textTokenized,
-			Channel:  channel,
-			Time:     message.Timestamp,
+			UserID:        userID,
+			UserName:      userName,
+			RealName:      realName,
+			Text:          textTokenized,
+			Channel:       channel,
+			ChannelName:   channelName,
+			ThreadTs:      message.ThreadTimestamp,
+			ReplyCount:    message.ReplyCount,
+			Time:          message.Timestamp,
+			Reactions:     formatReactions(message.Reactions, usersMap),
+			Files:         formatFiles(message.Files),
+			ReactionItems: message.Reactions,
+			FileItems:     message.Files,
 		})
 	}
 
-	if len(messageList) > 0 && messages.HasMore {
-		messageList[len(messageList)-1].Cursor = messages.ResponseMetaData.NextCursor
+	return messageList
+}
+
+// authorResolver determines the author of a message. Users missing from
+// the provider's map are fetched once per resolver, and messages posted by
+// bots or integrations fall back to their bot identity.
+type authorResolver struct {
+	ctx         context.Context
+	apiProvider *provider.ApiProvider
+	failed      map[string]bool
+}
+
+func newAuthorResolver(ctx context.Context, apiProvider *provider.ApiProvider) *authorResolver {
+	return &authorResolver{
+		ctx:         ctx,
+		apiProvider: apiProvider,
+		failed:      make(map[string]bool),
+	}
+}
+
+func (r *authorResolver) resolve(userID, botID, username string, botProfile *slack.BotProfile) (id, name, realName string) {
+	if userID != "" && !r.failed[userID] {
+		user, err := r.apiProvider.ProvideUser(r.ctx, userID)
+		if err == nil {
+			return user.ID, user.Name, user.RealName
+		}
+		log.Printf("Failed to resolve user %s: %v", userID, err)
+		r.failed[userID] = true
+	}
+
+	if botProfile != nil && botProfile.Name != "" {
+		realName = botProfile.Name
+	}
+	if username != "" {
+		name = username
+	} else {
+		name = realName
+	}
+
+	id = userID
+	if id == "" {
+		id = botID
+	}
+
+	return id, name, realName
+}
+
+// parseLimit converts the "limit" tool argument into Slack history
+// parameters. A limit with "d" suffix is handled by limitByDays, otherwise
+// it is treated as a message count unless a cursor is provided.
+func parseLimit(limit, cursor string, now time.Time) (slackLimit int, oldest, latest string, err error) {
+	if strings.HasSuffix(limit, "d") {
+		return limitByDays(limit, now)
+	}
+
+	if cursor == "" {
+		slackLimit, err = limitByNumeric(limit)
+		if err != nil {
+			return 0, "", "", err
+		}
+	}
+
+	return slackLimit, "", "", nil
+}
+
+// parseRange converts the oldest and latest arguments of
+// conversations_history to Slack timestamps. limit may then only be a
+// number of messages per page, and defaults to 100.
+func parseRange(limit, oldest, latest string, now time.Time) (slackLimit int, oldestTs, latestTs string, err error) {
+	slackLimit = 100
+	if limit != "" {
+		if strings.HasSuffix(limit, "d") {
+			return 0, "", "", errors.New("limit in days cannot be combined with oldest or latest, pass a number of messages instead")
+		}
+		if slackLimit, err = limitByNumeric(limit); err != nil {
+			return 0, "", "", err
+		}
+	}
+
+	if oldest != "" {
+		if oldestTs, err = parseTimestamp(oldest, now, false); err != nil {
+			return 0, "", "", fmt.Errorf("invalid oldest: %w", err)
+		}
+	}
+	if latest != "" {
+		if latestTs, err = parseTimestamp(latest, now, true); err != nil {
+			return 0, "", "", fmt.Errorf("invalid latest: %w", err)
+		}
+	}
+
+	if oldestTs != "" && latestTs != "" {
+		o, _ := strconv.ParseFloat(oldestTs, 64)
+		l, _ := strconv.ParseFloat(latestTs, 64)
+		if o > l {
+			return 0, "", "", fmt.Errorf("oldest %q is after latest %q", oldest, latest)
+		}
+	}
+
+	return slackLimit, oldestTs, latestTs, nil
+}
+
+// requestTextOptions applies the optional "text_mode" tool argument on top
+// of the server-wide text processing defaults.
+func (ch *ConversationsHandler) requestTextOptions(request mcp.CallToolRequest) (text.Options, error) {
+	opts := ch.textOptions
+
+	if mode := request.GetString("text_mode", ""); mode != "" {
+		m, err := text.ParseMode(mode)
+		if err != nil {
+			return text.Options{}, err
+		}
+		opts.Mode = m
 	}
 
-	csvBytes, err := gocsv.MarshalBytes(&messageList)
+	return opts, nil
+}
+
+// textOptionsFromEnv reads the server-wide text processing defaults from
+// SLACK_MCP_TEXT_MODE and SLACK_MCP_TEXT_LANGUAGE.
+func textOptionsFromEnv() text.Options {
+	opts := text.DefaultOptions
+
+	mode, err := text.ParseMode(os.Getenv("SLACK_MCP_TEXT_MODE"))
 	if err != nil {
-		return nil, err
+		log.Printf("Ignoring SLACK_MCP_TEXT_MODE: %v", err)
+	} else {
+		opts.Mode = mode
+	}
+
+	if language := os.Getenv("SLACK_MCP_TEXT_LANGUAGE"); language != "" {
+		code, err := text.ParseLanguage(language)
+		if err != nil {
+			log.Printf("Ignoring SLACK_MCP_TEXT_LANGUAGE, using %q: %v", opts.Language, err)
+		} else {
+			opts.Language = code
+		}
+	}
+
+	return opts
+}
+
+// mentionResolver resolves mention tokens using the provider's users map
+// and channel cache. Channels that cannot be fetched are tried once per
+// resolver.
+type mentionResolver struct {
+	ctx         context.Context
+	apiProvider *provider.ApiProvider
+	failed      map[string]bool
+}
+
+func newMentionResolver(ctx context.Context, apiProvider *provider.ApiProvider) *mentionResolver {
+	return &mentionResolver{
+		ctx:         ctx,
+		apiProvider: apiProvider,
+		failed:      make(map[string]bool),
+	}
+}
+
+func (r *mentionResolver) UserName(id string) (string, bool) {
+	user, ok := r.apiProvider.ProvideUsersMap()[id]
+	if !ok {
+		return "", false
+	}
+
+	return user.Name, true
+}
+
+func (r *mentionResolver) ChannelName(id string) (string, bool) {
+	if r.failed[id] {
+		return "", false
+	}
+
+	channel, err := r.apiProvider.ProvideChannel(r.ctx, id)
+	if err != nil || channel.Name == "" {
+		r.failed[id] = true
+		return "", false
+	}
+
+	return channel.Name, true
+}
+
+type searchFilters struct {
+	Query       string
+	InChannel   string
+	FromUser    string
+	Before      string
+	After       string
+	On          string
+	HasLink     bool
+	HasReaction bool
+	ThreadsOnly bool
+}
+
+// buildSearchQuery composes a Slack search query from the free-text query
+// and the search modifiers supported by the search_messages tool.
+func buildSearchQuery(f searchFilters) (string, error) {
+	var parts []string
+	if q := strings.TrimSpace(f.Query); q != "" {
+		parts = append(parts, q)
+	}
+
+	if f.InChannel != "" {
+		switch {
+		case provider.IsChannelID(f.InChannel):
+			parts = append(parts, "in:<#"+f.InChannel+">")
+		case strings.HasPrefix(f.InChannel, "@"):
+			parts = append(parts, "in:"+f.InChannel)
+		default:
+			parts = append(parts, "in:#"+strings.TrimPrefix(f.InChannel, "#"))
+		}
+	}
+
+	if f.FromUser != "" {
+		switch {
+		case provider.IsUserID(f.FromUser):
+			parts = append(parts, "from:<@"+f.FromUser+">")
+		default:
+			parts = append(parts, "from:@"+strings.TrimPrefix(f.FromUser, "@"))
+		}
+	}
+
+	for _, d := range []struct{ modifier, value string }{
+		{"before", f.Before},
+		{"after", f.After},
+		{"on", f.On},
+	} {
+		if d.value == "" {
+			continue
+		}
+		if _, err := time.Parse("2006-01-02", d.value); err != nil {
+			return "", fmt.Errorf("invalid filter_date_%s %q: must be in format YYYY-MM-DD", d.modifier, d.value)
+		}
+		parts = append(parts, d.modifier+":"+d.value)
+	}
+
+	if f.HasLink {
+		parts = append(parts, "has:link")
+	}
+	if f.HasReaction {
+		parts = append(parts, "has:reaction")
+	}
+	if f.ThreadsOnly {
+		parts = append(parts, "is:thread")
+	}
+
+	if len(parts) == 0 {
+		return "", errors.New("search_query or at least one filter must be provided")
+	}
+
+	return strings.Join(parts, " "), nil
+}
+
+// threadTsFromPermalink extracts the thread_ts query parameter that Slack
+// adds to permalinks of thread replies.
+func threadTsFromPermalink(permalink string) string {
+	_, query, ok := strings.Cut(permalink, "?")
+	if !ok {
+		return ""
+	}
+	for _, kv := range strings.Split(query, "&") {
+		if v, ok := strings.CutPrefix(kv, "thread_ts="); ok {
+			return v
+		}
 	}
 
-	return mcp.NewToolResultText(string(csvBytes)), nil
+	return ""
 }
 
 func limitByNumeric(limit string) (int, error) {
@@ -126,17 +722,16 @@ func limitByNumeric(limit string) (int, error) {
 // limitByDays parses a string like "1d", "2d", etc.
 // It returns:
 //   - the per page limit (100)
-//   - oldest timestamp = midnight of (today − days + 1),
+//   - oldest timestamp = midnight of (today − days + 1) in the timezone of now,
 //   - latest timestamp = now,
 //   - or an error if parsing fails.
-func limitByDays(limit string) (slackLimit int, oldest, latest string, err error) {
+func limitByDays(limit string, now time.Time) (slackLimit int, oldest, latest string, err error) {
 	daysStr := strings.TrimSuffix(limit, "d")
 	days, err := strconv.Atoi(daysStr)
 	if err != nil || days <= 0 {
 		return 0, "", "", fmt.Errorf("invalid duration limit %q: must be a positive integer with 'd' suffix", limit)
 	}
 
-	now := time.Now()
 	loc := now.Location()
 
 	startOfToday := time.Date(
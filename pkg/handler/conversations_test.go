@@ -0,0 +1,384 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/korotovsky/slack-mcp-server/pkg/provider"
+	"github.com/korotovsky/slack-mcp-server/pkg/text"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/slack-go/slack"
+)
+
+// newTestWorkspaces returns a workspace whose client talks to a fake Slack
+// API that serves routes by method name. users.list returns users and
+// conversations.info a channel named after its ID unless routes override
+// them; other methods fail with unknown_method.
+func newTestWorkspaces(t *testing.T, users []slack.User, routes map[string]http.HandlerFunc) *provider.Workspaces {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		w.Header().Set("Content-Type", "application/json")
+
+		method := strings.TrimPrefix(r.URL.Path, "/")
+		if route, ok := routes[method]; ok {
+			route(w, r)
+			return
+		}
+
+		switch method {
+		case "users.list":
+			json.NewEncoder(w).Encode(map[string]any{"ok": true, "members": users})
+		case "conversations.info":
+			id := r.Form.Get("channel")
+			json.NewEncoder(w).Encode(map[string]any{"ok": true, "channel": map[string]any{"id": id, "name": "channel-" + id}})
+		default:
+			json.NewEncoder(w).Encode(map[string]any{"ok": false, "error": "unknown_method"})
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	client := slack.New("xoxp-test", slack.OptionAPIURL(srv.URL+"/"))
+	return provider.NewSingleWorkspace(provider.NewWithClient(provider.DefaultWorkspace, client))
+}
+
+func callTool(args map[string]any) mcp.CallToolRequest {
+	var request mcp.CallToolRequest
+	request.Params.Arguments = args
+	return request
+}
+
+// writeJSON returns a route that responds with v.
+func writeJSON(v any) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(v)
+	}
+}
+
+func TestBuildSearchQuery(t *testing.T) {
+	tests := []struct {
+		name    string
+		filters searchFilters
+		want    string
+		wantErr bool
+	}{
+		{
+			name:    "Empty query and filters",
+			filters: searchFilters{},
+			wantErr: true,
+		},
+		{
+			name:    "Plain query",
+			filters: searchFilters{Query: "  deploy failed  "},
+			want:    "deploy failed",
+		},
+		{
+			name:    "Channel and user IDs",
+			filters: searchFilters{Query: "deploy", InChannel: "C0123456789", FromUser: "U0123456789"},
+			want:    "deploy in:<#C0123456789> from:<@U0123456789>",
+		},
+		{
+			name:    "Channel and user names",
+			filters: searchFilters{InChannel: "#general", FromUser: "@alice"},
+			want:    "in:#general from:@alice",
+		},
+		{
+			name:    "Direct message",
+			filters: searchFilters{InChannel: "@bob"},
+			want:    "in:@bob",
+		},
+		{
+			name:    "Dates and flags",
+			filters: searchFilters{Query: "x", After: "2024-01-01", Before: "2024-02-01", HasLink: true, HasReaction: true, ThreadsOnly: true},
+			want:    "x before:2024-02-01 after:2024-01-01 has:link has:reaction is:thread",
+		},
+		{
+			name:    "Invalid date",
+			filters: searchFilters{On: "01/02/2024"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := buildSearchQuery(tt.filters)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("buildSearchQuery() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("buildSearchQuery() = '%s', want '%s'", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestThreadTsFromPermalink(t *testing.T) {
+	tests := []struct {
+		permalink string
+		want      string
+	}{
+		{"https://team.slack.com/archives/C123/p1700000000123456", ""},
+		{"https://team.slack.com/archives/C123/p1700000000123456?thread_ts=1700000000.000100&cid=C123", "1700000000.000100"},
+	}
+
+	for _, tt := range tests {
+		if got := threadTsFromPermalink(tt.permalink); got != tt.want {
+			t.Errorf("threadTsFromPermalink(%q) = '%s', want '%s'", tt.permalink, got, tt.want)
+		}
+	}
+}
+
+func TestAuthorResolverBots(t *testing.T) {
+	r := newAuthorResolver(context.Background(), nil)
+
+	tests := []struct {
+		name         string
+		botID        string
+		username     string
+		botProfile   *slack.BotProfile
+		wantID       string
+		wantName     string
+		wantRealName string
+	}{
+		{
+			name:         "Bot profile only",
+			botID:        "B123",
+			botProfile:   &slack.BotProfile{Name: "Deploy Bot"},
+			wantID:       "B123",
+			wantName:     "Deploy Bot",
+			wantRealName: "Deploy Bot",
+		},
+		{
+			name:         "Integration with custom username",
+			botID:        "B123",
+			username:     "jenkins",
+			botProfile:   &slack.BotProfile{Name: "Jenkins CI"},
+			wantID:       "B123",
+			wantName:     "jenkins",
+			wantRealName: "Jenkins CI",
+		},
+		{
+			name:     "Bot without profile",
+			botID:    "B456",
+			wantID:   "B456",
+			wantName: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id, name, realName := r.resolve("", tt.botID, tt.username, tt.botProfile)
+			if id != tt.wantID || name != tt.wantName || realName != tt.wantRealName {
+				t.Errorf("resolve() = (%q, %q, %q), want (%q, %q, %q)", id, name, realName, tt.wantID, tt.wantName, tt.wantRealName)
+			}
+		})
+	}
+}
+
+func TestMentionResolverRemembersFailedChannels(t *testing.T) {
+	var lookups int
+	workspaces := newTestWorkspaces(t, nil, map[string]http.HandlerFunc{
+		"conversations.info": func(w http.ResponseWriter, r *http.Request) {
+			lookups++
+			writeJSON(map[string]any{"ok": false, "error": "channel_not_found"})(w, r)
+		},
+	})
+	apiProvider, err := workspaces.Provider("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := newMentionResolver(context.Background(), apiProvider)
+
+	for i := 0; i < 3; i++ {
+		if name, ok := r.ChannelName("C0123456789"); ok {
+			t.Errorf("ChannelName() = %q, want not found", name)
+		}
+	}
+	if lookups != 1 {
+		t.Errorf("conversations.info called %d times, want 1", lookups)
+	}
+}
+
+func TestConvertMessagesRawMode(t *testing.T) {
+	workspaces := newTestWorkspaces(t, []slack.User{{ID: "U0000000001", Name: "alice"}}, nil)
+	apiProvider, err := workspaces.Provider("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	messages := []slack.Message{{Msg: slack.Msg{Text: "ping <@U0000000001> in <#C0123456789>", Timestamp: "1700000000.000000"}}}
+
+	tests := []struct {
+		mode text.Mode
+		want string
+	}{
+		{text.ModeRaw, "ping <@U0000000001> in <#C0123456789>"},
+		{text.ModeNormalized, "ping @alice in #channel-C0123456789"},
+	}
+	for _, tt := range tests {
+		got := convertMessages(context.Background(), apiProvider, messages, "C0123456789", text.Options{Mode: tt.mode})
+		if got[0].Text != tt.want {
+			t.Errorf("convertMessages() in %s mode = %q, want %q", tt.mode, got[0].Text, tt.want)
+		}
+	}
+}
+
+func TestConversationsAddMessageThreadReply(t *testing.T) {
+	t.Setenv("SLACK_MCP_ADD_MESSAGE_TOOL", "true")
+	t.Setenv("SLACK_MCP_OUTPUT_FORMAT", "json")
+
+	workspaces := newTestWorkspaces(t, nil, map[string]http.HandlerFunc{
+		"chat.postMessage": writeJSON(map[string]any{"ok": true, "channel": "C0123456789", "ts": "1700000000.000200"}),
+		// Like Slack, the thread parent comes first.
+		"conversations.replies": writeJSON(map[string]any{
+			"ok": true,
+			"messages": []map[string]any{
+				{"type": "message", "ts": "1700000000.000100", "thread_ts": "1700000000.000100", "text": "parent"},
+				{"type": "message", "ts": "1700000000.000200", "thread_ts": "1700000000.000100", "text": "reply"},
+			},
+		}),
+	})
+	ch := NewConversationsHandler(workspaces)
+
+	result, err := ch.ConversationsAddMessageHandler(context.Background(), callTool(map[string]any{
+		"channel_id":   "C0123456789",
+		"thread_ts":    "1700000000.000100",
+		"payload":      "reply",
+		"content_type": "text/plain",
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var messages []Message
+	if err := json.Unmarshal([]byte(resultText(t, result)), &messages); err != nil {
+		t.Fatal(err)
+	}
+	if len(messages) != 1 || messages[0].Time != "1700000000.000200" || messages[0].Text != "reply" {
+		t.Errorf("ConversationsAddMessageHandler() = %+v, want the posted reply", messages)
+	}
+}
+
+func TestConversationsHistoryRangeWithLimit(t *testing.T) {
+	t.Setenv("SLACK_MCP_OUTPUT_FORMAT", "json")
+
+	var limits []string
+	workspaces := newTestWorkspaces(t, nil, map[string]http.HandlerFunc{
+		"conversations.history": func(w http.ResponseWriter, r *http.Request) {
+			limits = append(limits, r.Form.Get("limit"))
+			var messages []map[string]any
+			for i := 0; i < 100; i++ {
+				messages = append(messages, map[string]any{"type": "message", "ts": fmt.Sprintf("%d.000000", 1700000000-i), "text": "x"})
+			}
+			writeJSON(map[string]any{"ok": true, "messages": messages, "has_more": true,
+				"response_metadata": map[string]string{"next_cursor": "next"}})(w, r)
+		},
+	})
+	ch := NewConversationsHandler(workspaces)
+
+	result, err := ch.ConversationsHistoryHandler(context.Background(), callTool(map[string]any{
+		"channel_id": "C0123456789",
+		"oldest":     "1w",
+		"limit":      "20",
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var messages []Message
+	if err := json.Unmarshal([]byte(resultText(t, result)), &messages); err != nil {
+		t.Fatal(err)
+	}
+	if len(messages) > 20 {
+		t.Errorf("got %d messages, want at most 20", len(messages))
+	}
+	if len(limits) != 1 || limits[0] != "20" {
+		t.Errorf("conversations.history called with limits %v, want [20]", limits)
+	}
+}
+
+func TestConversationsRepliesOldThread(t *testing.T) {
+	t.Setenv("SLACK_MCP_OUTPUT_FORMAT", "json")
+
+	parent := time.Now().AddDate(0, 0, -3).Unix()
+	thread := []map[string]any{
+		{"type": "message", "ts": fmt.Sprintf("%d.000100", parent), "thread_ts": fmt.Sprintf("%d.000100", parent), "reply_count": 2, "text": "parent"},
+		{"type": "message", "ts": fmt.Sprintf("%d.000200", parent), "thread_ts": fmt.Sprintf("%d.000100", parent), "text": "early"},
+		{"type": "message", "ts": fmt.Sprintf("%d.000100", time.Now().Unix()), "thread_ts": fmt.Sprintf("%d.000100", parent), "text": "late"},
+	}
+
+	var limits []string
+	workspaces := newTestWorkspaces(t, nil, map[string]http.HandlerFunc{
+		// Like Slack, messages before oldest are dropped.
+		"conversations.replies": func(w http.ResponseWriter, r *http.Request) {
+			limits = append(limits, r.Form.Get("limit"))
+			var messages []map[string]any
+			for _, message := range thread {
+				if message["ts"].(string) >= r.Form.Get("oldest") {
+					messages = append(messages, message)
+				}
+			}
+			writeJSON(map[string]any{"ok": true, "messages": messages})(w, r)
+		},
+	})
+	ch := NewConversationsHandler(workspaces)
+
+	result, err := ch.ConversationsRepliesHandler(context.Background(), callTool(map[string]any{
+		"channel_id": "C0123456789",
+		"thread_ts":  fmt.Sprintf("%d.000100", parent),
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var messages []Message
+	if err := json.Unmarshal([]byte(resultText(t, result)), &messages); err != nil {
+		t.Fatal(err)
+	}
+	if len(messages) != 3 || messages[0].Text != "parent" || messages[1].Text != "early" {
+		t.Errorf("ConversationsRepliesHandler() = %+v, want the whole thread", messages)
+	}
+	if len(limits) != 1 || limits[0] != defaultRepliesLimit {
+		t.Errorf("conversations.replies called with limits %v, want [%s]", limits, defaultRepliesLimit)
+	}
+}
+
+func TestConversationsHistoryDefaultLimit(t *testing.T) {
+	t.Setenv("SLACK_MCP_OUTPUT_FORMAT", "json")
+
+	var oldest []string
+	workspaces := newTestWorkspaces(t, nil, map[string]http.HandlerFunc{
+		"conversations.history": func(w http.ResponseWriter, r *http.Request) {
+			oldest = append(oldest, r.Form.Get("oldest"))
+			writeJSON(map[string]any{"ok": true, "messages": []map[string]any{}})(w, r)
+		},
+	})
+	ch := NewConversationsHandler(workspaces)
+
+	if _, err := ch.ConversationsHistoryHandler(context.Background(), callTool(map[string]any{
+		"channel_id": "C0123456789",
+	})); err != nil {
+		t.Fatal(err)
+	}
+	if len(oldest) != 1 || oldest[0] == "" {
+		t.Fatalf("conversations.history called with oldest %q, want the start of today", oldest)
+	}
+
+	// An explicit range replaces the default day.
+	oldest = nil
+	if _, err := ch.ConversationsHistoryHandler(context.Background(), callTool(map[string]any{
+		"channel_id": "C0123456789",
+		"oldest":     "1700000000.000000",
+	})); err != nil {
+		t.Fatal(err)
+	}
+	if len(oldest) != 1 || oldest[0] != "1700000000.000000" {
+		t.Errorf("conversations.history called with oldest %q, want [1700000000.000000]", oldest)
+	}
+}
@@ -0,0 +1,523 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/korotovsky/slack-mcp-server/pkg/provider"
+	"github.com/ledongthuc/pdf"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/slack-go/slack"
+)
+
+const defaultFileMaxBytes = 1 << 20
+
+// fileTypes are the type filters accepted by files.list.
+var fileTypes = map[string]bool{
+	"all":      true,
+	"spaces":   true,
+	"snippets": true,
+	"images":   true,
+	"gdocs":    true,
+	"zips":     true,
+	"pdfs":     true,
+}
+
+// textMimetypes are the non text/* MIME types whose content is returned
+// as text.
+var textMimetypes = map[string]bool{
+	"application/json":       true,
+	"application/xml":        true,
+	"application/javascript": true,
+	"application/x-yaml":     true,
+	"application/yaml":       true,
+	"application/x-sh":       true,
+	"application/sql":        true,
+	"application/csv":        true,
+}
+
+var errFileTooLarge = errors.New("file exceeds the size limit")
+
+type File struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Title     string `json:"title"`
+	Filetype  string `json:"filetype"`
+	Mimetype  string `json:"mimetype"`
+	Size      int    `json:"size"`
+	UserID    string `json:"userID"`
+	UserName  string `json:"userName"`
+	Channels  string `json:"channels"`
+	Created   string `json:"created"`
+	Permalink string `json:"permalink"`
+	Cursor    string `json:"cursor"`
+}
+
+type FilesHandler struct {
+	workspaces  *provider.Workspaces
+	writePolicy *WritePolicy
+	format      Format
+	location    *time.Location
+	maxBytes    int
+}
+
+func NewFilesHandler(workspaces *provider.Workspaces) *FilesHandler {
+	return &FilesHandler{
+		workspaces:  workspaces,
+		writePolicy: NewWritePolicyFromEnv(),
+		format:      formatFromEnv(),
+		location:    locationFromEnv(),
+		maxBytes:    intFromEnv("SLACK_MCP_FILE_MAX_BYTES", defaultFileMaxBytes, 1),
+	}
+}
+
+func (fh *FilesHandler) FilesListHandler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	limit := request.GetInt("limit", 20)
+	if limit <= 0 || limit > 100 {
+		return nil, fmt.Errorf("invalid limit %d: must be between 1 and 100", limit)
+	}
+
+	params := slack.GetFilesParameters{
+		Count: limit,
+		Page:  1,
+	}
+
+	if types := strings.ReplaceAll(request.GetString("types", ""), " ", ""); types != "" {
+		for _, t := range strings.Split(types, ",") {
+			if !fileTypes[t] {
+				return nil, fmt.Errorf("invalid file type %q: allowed values are all, spaces, snippets, images, gdocs, zips, pdfs", t)
+			}
+		}
+		params.Types = types
+	}
+
+	now := time.Now().In(fh.location)
+	if oldest := request.GetString("oldest", ""); oldest != "" {
+		ts, err := parseTimestamp(oldest, now, false)
+		if err != nil {
+			return nil, err
+		}
+		params.TimestampFrom = slack.JSONTime(tsSeconds(ts))
+	}
+	if latest := request.GetString("latest", ""); latest != "" {
+		ts, err := parseTimestamp(latest, now, true)
+		if err != nil {
+			return nil, err
+		}
+		params.TimestampTo = slack.JSONTime(tsSeconds(ts))
+	}
+
+	if cursor := request.GetString("cursor", ""); cursor != "" {
+		page, err := strconv.Atoi(strings.TrimPrefix(cursor, "page:"))
+		if err != nil || page <= 0 {
+			return nil, fmt.Errorf("invalid cursor %q", cursor)
+		}
+		params.Page = page
+	}
+
+	format, err := requestFormat(request, fh.format)
+	if err != nil {
+		return nil, err
+	}
+
+	apiProvider, err := fh.workspaces.Provider(request.GetString("workspace", ""))
+	if err != nil {
+		return nil, err
+	}
+
+	api, err := apiProvider.Provide()
+	if err != nil {
+		return nil, err
+	}
+
+	if channel := request.GetString("channel_id", ""); channel != "" {
+		params.Channel, err = apiProvider.ResolveChannel(ctx, channel)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if user := strings.TrimSpace(request.GetString("user", "")); user != "" {
+		if name, ok := strings.CutPrefix(user, "@"); ok {
+			found, err := apiProvider.UserByName(name)
+			if err != nil {
+				return nil, err
+			}
+			user = found.ID
+		}
+		params.User = user
+	}
+
+	files, paging, err := api.GetFilesContext(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	usersMap := apiProvider.ProvideUsersMap()
+	fileList := make([]File, 0, len(files))
+	for _, file := range files {
+		fileList = append(fileList, toFile(file, usersMap))
+	}
+
+	if len(fileList) > 0 && paging != nil && paging.Page < paging.Pages {
+		fileList[len(fileList)-1].Cursor = fmt.Sprintf("page:%d", paging.Page+1)
+	}
+
+	return rowsResult(fileList, format)
+}
+
+// FileGetHandler returns the metadata of a file followed by its content:
+// text for text-like files, the extracted text layer for PDFs, and an
+// embedded resource for anything else, such as images. Files larger than
+// the configured limit are reported without content, except for the text
+// preview Slack keeps of some PDFs.
+func (fh *FilesHandler) FileGetHandler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	fileID := strings.TrimSpace(request.GetString("file_id", ""))
+	if fileID == "" {
+		return nil, errors.New("file_id must be a string")
+	}
+
+	format, err := requestFormat(request, fh.format)
+	if err != nil {
+		return nil, err
+	}
+
+	apiProvider, err := fh.workspaces.Provider(request.GetString("workspace", ""))
+	if err != nil {
+		return nil, err
+	}
+
+	api, err := apiProvider.Provide()
+	if err != nil {
+		return nil, err
+	}
+
+	file, _, _, err := api.GetFileInfoContext(ctx, fileID, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := rowsResult([]File{toFile(*file, apiProvider.ProvideUsersMap())}, format)
+	if err != nil {
+		return nil, err
+	}
+
+	if file.Size > fh.maxBytes {
+		result.Content = append(result.Content, tooLargeContent(file, fmt.Sprintf(
+			"Content not returned: the file has %d bytes, more than the limit of %d bytes set by SLACK_MCP_FILE_MAX_BYTES.",
+			file.Size, fh.maxBytes)))
+		return result, nil
+	}
+
+	data, err := downloadFile(ctx, api, file, fh.maxBytes)
+	if errors.Is(err, errFileTooLarge) {
+		result.Content = append(result.Content, tooLargeContent(file, fmt.Sprintf(
+			"Content not returned: the file is larger than the limit of %d bytes set by SLACK_MCP_FILE_MAX_BYTES.", fh.maxBytes)))
+		return result, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	result.Content = append(result.Content, fileContent(file, data)...)
+
+	return result, nil
+}
+
+// FilesUploadHandler shares content as a file in a channel or thread using
+// Slack's external upload flow, and returns the uploaded file.
+func (fh *FilesHandler) FilesUploadHandler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	channel := request.GetString("channel_id", "")
+	if channel == "" {
+		return nil, errors.New("channel_id must be a string")
+	}
+
+	threadTs := request.GetString("thread_ts", "")
+	if threadTs != "" && !strings.Contains(threadTs, ".") {
+		return nil, errors.New("thread_ts must be a valid timestamp in format 1234567890.123456")
+	}
+
+	filename := strings.TrimSpace(request.GetString("filename", ""))
+	if filename == "" {
+		return nil, errors.New("filename must be a non-empty string")
+	}
+
+	data, err := decodeContent(request.GetString("content", ""), request.GetString("content_encoding", "text"))
+	if err != nil {
+		return nil, err
+	}
+
+	title := request.GetString("title", "")
+	if title == "" {
+		title = filename
+	}
+
+	format, err := requestFormat(request, fh.format)
+	if err != nil {
+		return nil, err
+	}
+
+	apiProvider, err := fh.workspaces.Provider(request.GetString("workspace", ""))
+	if err != nil {
+		return nil, err
+	}
+
+	api, err := apiProvider.Provide()
+	if err != nil {
+		return nil, err
+	}
+
+	channel, err = fh.writePolicy.Resolve(ctx, apiProvider, channel)
+	if err != nil {
+		return nil, err
+	}
+
+	summary, err := api.UploadFileV2Context(ctx, slack.UploadFileV2Parameters{
+		Reader:          bytes.NewReader(data),
+		FileSize:        len(data),
+		Filename:        filename,
+		Title:           title,
+		InitialComment:  request.GetString("initial_comment", ""),
+		Channel:         channel,
+		ThreadTimestamp: threadTs,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	row := readBack("file "+summary.ID, func() (File, error) {
+		file, _, _, err := api.GetFileInfoContext(ctx, summary.ID, 0, 0)
+		if err != nil {
+			return File{}, err
+		}
+		return toFile(*file, apiProvider.ProvideUsersMap()), nil
+	}, File{ID: summary.ID, Name: filename, Title: summary.Title, Size: len(data), Channels: channel})
+
+	return rowsResult([]File{row}, format)
+}
+
+// decodeContent returns the bytes of upload content given as text or as
+// base64 for binary files.
+func decodeContent(content, encoding string) ([]byte, error) {
+	if content == "" {
+		return nil, errors.New("content must be a non-empty string")
+	}
+
+	switch encoding {
+	case "", "text":
+		return []byte(content), nil
+	case "base64":
+		data, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(content), ""))
+		if err != nil {
+			return nil, fmt.Errorf("content is not valid base64: %w", err)
+		}
+		if len(data) == 0 {
+			return nil, errors.New("content must not be empty")
+		}
+		return data, nil
+	default:
+		return nil, errors.New("content_encoding must be either 'text' or 'base64'")
+	}
+}
+
+// downloadFile fetches the content of file through the client's
+// authenticated transport, reading at most maxBytes.
+func downloadFile(ctx context.Context, api *slack.Client, file *slack.File, maxBytes int) ([]byte, error) {
+	url := file.URLPrivateDownload
+	if url == "" {
+		url = file.URLPrivate
+	}
+	if url == "" {
+		return nil, fmt.Errorf("file %s has no downloadable content", file.ID)
+	}
+
+	w := &limitedBuffer{max: maxBytes}
+	if err := api.GetFileContext(ctx, url, w); err != nil {
+		return nil, err
+	}
+
+	return w.buf.Bytes(), nil
+}
+
+// limitedBuffer is a buffer that fails writes past max bytes, which stops
+// a download as soon as it exceeds the limit.
+type limitedBuffer struct {
+	buf bytes.Buffer
+	max int
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if b.buf.Len()+len(p) > b.max {
+		return 0, errFileTooLarge
+	}
+
+	return b.buf.Write(p)
+}
+
+// fileContent returns text content for text files, the text layer of
+// PDFs, and an embedded resource with the raw bytes for everything else.
+// When no text can be extracted from a PDF, Slack's preview is returned
+// instead, or a note followed by the PDF itself.
+func fileContent(file *slack.File, data []byte) []mcp.Content {
+	if isTextFile(file) && utf8.Valid(data) {
+		return []mcp.Content{mcp.NewTextContent(string(data))}
+	}
+
+	if isPDF(file) {
+		text, err := pdfText(data)
+		if err == nil {
+			return []mcp.Content{mcp.NewTextContent(text)}
+		}
+		log.Printf("Failed to extract text from file %s: %v", file.ID, err)
+		if preview, ok := pdfPreview(file); ok {
+			return []mcp.Content{preview}
+		}
+		return []mcp.Content{
+			mcp.NewTextContent(fmt.Sprintf("No text could be extracted from this PDF (%v); it follows as an embedded resource.", err)),
+			blobContent(file, data),
+		}
+	}
+
+	return []mcp.Content{blobContent(file, data)}
+}
+
+// tooLargeContent returns Slack's preview of a PDF that is too large to
+// download, or note otherwise.
+func tooLargeContent(file *slack.File, note string) mcp.Content {
+	if preview, ok := pdfPreview(file); ok {
+		return preview
+	}
+
+	return mcp.NewTextContent(note)
+}
+
+// blobContent returns the raw bytes of a file as an embedded resource.
+func blobContent(file *slack.File, data []byte) mcp.Content {
+	uri := file.Permalink
+	if uri == "" {
+		uri = file.URLPrivate
+	}
+
+	mimetype := file.Mimetype
+	if mimetype == "" {
+		mimetype = "application/octet-stream"
+	}
+
+	return mcp.NewEmbeddedResource(mcp.BlobResourceContents{
+		URI:      uri,
+		MIMEType: mimetype,
+		Blob:     base64.StdEncoding.EncodeToString(data),
+	})
+}
+
+// pdfText extracts the text layer of a PDF. It fails for scanned PDFs
+// without a text layer, and for encrypted or malformed ones.
+func pdfText(data []byte) (text string, err error) {
+	// The PDF reader panics on some malformed files.
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("malformed PDF: %v", r)
+		}
+	}()
+
+	reader, err := pdf.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return "", err
+	}
+	plain, err := reader.GetPlainText()
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if _, err := buf.ReadFrom(plain); err != nil {
+		return "", err
+	}
+
+	text = strings.TrimSpace(buf.String())
+	if text == "" {
+		return "", errors.New("the PDF has no text layer")
+	}
+
+	return text, nil
+}
+
+// pdfPreview returns the text Slack extracted from a PDF as its preview,
+// which files.info includes for some PDFs.
+func pdfPreview(file *slack.File) (mcp.Content, bool) {
+	if !isPDF(file) || strings.TrimSpace(file.Preview) == "" {
+		return nil, false
+	}
+
+	preview := file.Preview
+	if file.LinesMore > 0 {
+		preview += fmt.Sprintf("\n\n[Slack's preview omits %d more lines of this PDF.]", file.LinesMore)
+	}
+
+	return mcp.NewTextContent(preview), true
+}
+
+func isPDF(file *slack.File) bool {
+	mimetype, _, _ := strings.Cut(file.Mimetype, ";")
+	return strings.TrimSpace(mimetype) == "application/pdf"
+}
+
+func isTextFile(file *slack.File) bool {
+	mimetype, _, _ := strings.Cut(file.Mimetype, ";")
+	mimetype = strings.TrimSpace(mimetype)
+
+	return strings.HasPrefix(mimetype, "text/") || textMimetypes[mimetype] || file.Mode == "snippet"
+}
+
+func toFile(file slack.File, usersMap map[string]slack.User) File {
+	userName := ""
+	if user, ok := usersMap[file.User]; ok {
+		userName = user.Name
+	}
+
+	var channels []string
+	channels = append(channels, file.Channels...)
+	channels = append(channels, file.Groups...)
+	channels = append(channels, file.IMs...)
+
+	return File{
+		ID:        file.ID,
+		Name:      file.Name,
+		Title:     file.Title,
+		Filetype:  file.Filetype,
+		Mimetype:  file.Mimetype,
+		Size:      file.Size,
+		UserID:    file.User,
+		UserName:  userName,
+		Channels:  strings.Join(channels, ","),
+		Created:   formatTs(file.Created.Time()),
+		Permalink: file.Permalink,
+	}
+}
+
+// formatFiles renders the files attached to a message compactly as
+// "id:mimetype:size:name" joined by ";", e.g.
+// "F0123456789:application/pdf:48213:report.pdf". The name comes last as
+// it may contain colons.
+func formatFiles(files []slack.File) string {
+	parts := make([]string, 0, len(files))
+	for _, file := range files {
+		parts = append(parts, file.ID+":"+file.Mimetype+":"+strconv.Itoa(file.Size)+":"+file.Name)
+	}
+
+	return strings.Join(parts, ";")
+}
+
+// tsSeconds returns the whole seconds of a Slack timestamp.
+func tsSeconds(ts string) int64 {
+	seconds, _, _ := strings.Cut(ts, ".")
+	n, _ := strconv.ParseInt(seconds, 10, 64)
+	return n
+}
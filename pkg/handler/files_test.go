@@ -0,0 +1,213 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/slack-go/slack"
+)
+
+func TestFormatFiles(t *testing.T) {
+	files := []slack.File{
+		{ID: "F1", Name: "report.pdf", Mimetype: "application/pdf", Size: 48213},
+		{ID: "F2", Name: "notes: draft.txt", Mimetype: "text/plain", Size: 12},
+	}
+
+	want := "F1:application/pdf:48213:report.pdf;F2:text/plain:12:notes: draft.txt"
+	if got := formatFiles(files); got != want {
+		t.Errorf("formatFiles() = %q, want %q", got, want)
+	}
+	if got := formatFiles(nil); got != "" {
+		t.Errorf("formatFiles(nil) = %q, want empty", got)
+	}
+}
+
+func TestDownloadFile(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer xoxb-test" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(strings.Repeat("x", 100)))
+	}))
+	defer srv.Close()
+	api := slack.New("xoxb-test")
+	file := &slack.File{ID: "F1", URLPrivateDownload: srv.URL + "/files-pri/T1-F1/download/x.txt"}
+
+	data, err := downloadFile(context.Background(), api, file, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data) != 100 {
+		t.Errorf("downloadFile() returned %d bytes, want 100", len(data))
+	}
+
+	if _, err := downloadFile(context.Background(), api, file, 99); !errors.Is(err, errFileTooLarge) {
+		t.Errorf("downloadFile() over the limit = %v, want errFileTooLarge", err)
+	}
+
+	if _, err := downloadFile(context.Background(), api, &slack.File{ID: "F2"}, 100); err == nil {
+		t.Error("downloadFile() without URL succeeded")
+	}
+}
+
+func TestFileContent(t *testing.T) {
+	tests := []struct {
+		name     string
+		file     slack.File
+		data     []byte
+		wantText bool
+	}{
+		{"text", slack.File{Mimetype: "text/plain"}, []byte("hello"), true},
+		{"csv", slack.File{Mimetype: "text/csv; charset=utf-8"}, []byte("a,b"), true},
+		{"json", slack.File{Mimetype: "application/json"}, []byte("{}"), true},
+		{"snippet", slack.File{Mimetype: "application/octet-stream", Mode: "snippet"}, []byte("x := 1"), true},
+		{"invalid utf-8", slack.File{Mimetype: "text/plain"}, []byte{0xff, 0xfe}, false},
+		{"image", slack.File{Mimetype: "image/png"}, []byte{0x89, 'P', 'N', 'G'}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.file.Permalink = "https://team.slack.com/files/U1/F1/x"
+			contents := fileContent(&tt.file, tt.data)
+			if len(contents) != 1 {
+				t.Fatalf("fileContent() = %d contents, want 1", len(contents))
+			}
+			content := contents[0]
+
+			if tt.wantText {
+				text, ok := content.(mcp.TextContent)
+				if !ok || text.Text != string(tt.data) {
+					t.Errorf("fileContent() = %#v, want text %q", content, tt.data)
+				}
+				return
+			}
+
+			resource, ok := content.(mcp.EmbeddedResource)
+			if !ok {
+				t.Fatalf("fileContent() = %#v, want an embedded resource", content)
+			}
+			blob, ok := resource.Resource.(mcp.BlobResourceContents)
+			if !ok || blob.Blob != base64.StdEncoding.EncodeToString(tt.data) || blob.URI != tt.file.Permalink {
+				t.Errorf("fileContent() resource = %#v", resource.Resource)
+			}
+		})
+	}
+}
+
+// minimalPDF returns a single page PDF that shows text in Helvetica.
+func minimalPDF(text string) []byte {
+	stream := "BT /F1 12 Tf 72 720 Td (" + text + ") Tj ET"
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Contents 4 0 R /Resources << /Font << /F1 5 0 R >> >> >>",
+		fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(stream), stream),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>",
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, object := range objects {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, object)
+	}
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+
+	return buf.Bytes()
+}
+
+func TestFileContentPDF(t *testing.T) {
+	file := slack.File{ID: "F1", Mimetype: "application/pdf", Permalink: "https://team.slack.com/files/U1/F1/x"}
+
+	contents := fileContent(&file, minimalPDF("Quarterly report"))
+	if len(contents) != 1 {
+		t.Fatalf("fileContent() = %d contents, want the text only", len(contents))
+	}
+	if text, ok := contents[0].(mcp.TextContent); !ok || !strings.Contains(text.Text, "Quarterly report") {
+		t.Errorf("fileContent() = %#v, want the extracted text", contents[0])
+	}
+
+	// Without a text layer Slack's preview is used, if there is one.
+	withPreview := file
+	withPreview.Preview = "Slack preview"
+	contents = fileContent(&withPreview, []byte("%PDF-1.7"))
+	if text, ok := contents[0].(mcp.TextContent); len(contents) != 1 || !ok || text.Text != "Slack preview" {
+		t.Errorf("fileContent() without text layer = %#v, want the preview", contents)
+	}
+
+	// Otherwise a note is followed by the PDF itself.
+	contents = fileContent(&file, []byte("%PDF-1.7"))
+	if len(contents) != 2 {
+		t.Fatalf("fileContent() without text layer or preview = %d contents, want 2", len(contents))
+	}
+	if text, ok := contents[0].(mcp.TextContent); !ok || !strings.Contains(text.Text, "No text could be extracted") {
+		t.Errorf("fileContent() note = %#v", contents[0])
+	}
+	if _, ok := contents[1].(mcp.EmbeddedResource); !ok {
+		t.Errorf("fileContent() = %#v, want the PDF as an embedded resource", contents[1])
+	}
+}
+
+func TestPDFPreview(t *testing.T) {
+	tests := []struct {
+		name   string
+		file   slack.File
+		want   string
+		wantOK bool
+	}{
+		{"preview", slack.File{Mimetype: "application/pdf", Preview: "Quarterly report"}, "Quarterly report", true},
+		{"truncated", slack.File{Mimetype: "application/pdf", Preview: "Quarterly report", LinesMore: 12},
+			"Quarterly report\n\n[Slack's preview omits 12 more lines of this PDF.]", true},
+		{"no preview", slack.File{Mimetype: "application/pdf"}, "", false},
+		{"not a pdf", slack.File{Mimetype: "text/plain", Preview: "hello"}, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			content, ok := pdfPreview(&tt.file)
+			if ok != tt.wantOK {
+				t.Fatalf("pdfPreview() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if !ok {
+				return
+			}
+			if text, isText := content.(mcp.TextContent); !isText || text.Text != tt.want {
+				t.Errorf("pdfPreview() = %#v, want text %q", content, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeContent(t *testing.T) {
+	tests := []struct {
+		content  string
+		encoding string
+		want     string
+		wantErr  bool
+	}{
+		{"hello", "text", "hello", false},
+		{"aGVs\nbG8=", "base64", "hello", false},
+		{"not base64!", "base64", "", true},
+		{"", "text", "", true},
+		{"hello", "hex", "", true},
+	}
+	for _, tt := range tests {
+		got, err := decodeContent(tt.content, tt.encoding)
+		if (err != nil) != tt.wantErr || string(got) != tt.want {
+			t.Errorf("decodeContent(%q, %q) = %q, %v", tt.content, tt.encoding, got, err)
+		}
+	}
+}
@@ -0,0 +1,282 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gocarina/gocsv"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// Format is the output format of tool results.
+type Format string
+
+const (
+	// FormatCSV renders one CSV row per item, the historical default.
+	FormatCSV Format = "csv"
+	// FormatJSON renders a JSON array of items.
+	FormatJSON Format = "json"
+	// FormatJSONL renders one JSON object per line.
+	FormatJSONL Format = "jsonl"
+	// FormatMarkdown renders messages as a chat transcript grouped by day
+	// and thread, and other items as a Markdown table.
+	FormatMarkdown Format = "markdown"
+)
+
+// AllFormats lists the supported output formats.
+var AllFormats = []Format{FormatCSV, FormatJSON, FormatJSONL, FormatMarkdown}
+
+// ParseFormat parses an output format name. An empty name selects CSV.
+func ParseFormat(s string) (Format, error) {
+	switch f := Format(strings.ToLower(strings.TrimSpace(s))); f {
+	case "":
+		return FormatCSV, nil
+	case FormatCSV, FormatJSON, FormatJSONL, FormatMarkdown:
+		return f, nil
+	case "md":
+		return FormatMarkdown, nil
+	default:
+		return "", fmt.Errorf("invalid format %q: allowed values are 'csv', 'json', 'jsonl' and 'markdown'", s)
+	}
+}
+
+// formatFromEnv reads the default output format from SLACK_MCP_OUTPUT_FORMAT.
+func formatFromEnv() Format {
+	f, err := ParseFormat(os.Getenv("SLACK_MCP_OUTPUT_FORMAT"))
+	if err != nil {
+		log.Printf("Ignoring SLACK_MCP_OUTPUT_FORMAT: %v", err)
+		return FormatCSV
+	}
+
+	return f
+}
+
+// requestFormat applies the optional "format" tool argument on top of the
+// server-wide default.
+func requestFormat(request mcp.CallToolRequest, fallback Format) (Format, error) {
+	if f := request.GetString("format", ""); f != "" {
+		return ParseFormat(f)
+	}
+
+	return fallback, nil
+}
+
+// rowsResult renders rows in the given format. Markdown renders a table
+// with the CSV columns.
+func rowsResult[T any](rows []T, format Format) (*mcp.CallToolResult, error) {
+	var (
+		out []byte
+		err error
+	)
+	switch format {
+	case FormatJSON:
+		if rows == nil {
+			rows = []T{}
+		}
+		out, err = json.Marshal(rows)
+	case FormatJSONL:
+		var buf bytes.Buffer
+		enc := json.NewEncoder(&buf)
+		for _, row := range rows {
+			if err = enc.Encode(row); err != nil {
+				break
+			}
+		}
+		out = buf.Bytes()
+	case FormatMarkdown:
+		out, err = markdownTable(rows)
+	default:
+		out, err = gocsv.MarshalBytes(&rows)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return mcp.NewToolResultStructured(structuredRows(rows), string(out)), nil
+}
+
+// structuredRows returns the rows as the structured content of a result.
+// Structured content must be a JSON object, so the rows are wrapped.
+func structuredRows[T any](rows []T) map[string]any {
+	if rows == nil {
+		rows = []T{}
+	}
+
+	return map[string]any{"rows": rows}
+}
+
+// messagesResult renders messages in the given format, using a chat
+// transcript for Markdown.
+func messagesResult(messages []Message, format Format, loc *time.Location) (*mcp.CallToolResult, error) {
+	if format == FormatMarkdown {
+		return mcp.NewToolResultStructured(structuredRows(messages), markdownTranscript(messages, loc)), nil
+	}
+
+	return rowsResult(messages, format)
+}
+
+func markdownTable[T any](rows []T) ([]byte, error) {
+	csvBytes, err := gocsv.MarshalBytes(&rows)
+	if err != nil {
+		return nil, err
+	}
+
+	records, err := csv.NewReader(bytes.NewReader(csvBytes)).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	cell := strings.NewReplacer("|", `\|`, "\r\n", "<br>", "\n", "<br>")
+
+	var b strings.Builder
+	for i, record := range records {
+		b.WriteString("|")
+		for _, value := range record {
+			b.WriteString(" " + cell.Replace(value) + " |")
+		}
+		b.WriteString("\n")
+		if i == 0 {
+			b.WriteString("|" + strings.Repeat(" --- |", len(record)) + "\n")
+		}
+	}
+
+	return []byte(b.String()), nil
+}
+
+// markdownTranscript renders messages oldest first, grouped by the day of
+// their thread's first message, with thread replies indented below their
+// parent.
+func markdownTranscript(messages []Message, loc *time.Location) string {
+	if len(messages) == 0 {
+		return "_No messages._\n"
+	}
+
+	type thread struct {
+		root     string
+		messages []Message
+	}
+
+	threads := make(map[string]*thread)
+	var (
+		roots    []string
+		cursor   string
+		channels = make(map[string]bool)
+	)
+	for _, message := range messages {
+		if message.Cursor != "" {
+			cursor = message.Cursor
+		}
+		channels[message.Channel] = true
+
+		root := message.ThreadTs
+		if root == "" {
+			root = message.Time
+		}
+		t, ok := threads[root]
+		if !ok {
+			t = &thread{root: root}
+			threads[root] = t
+			roots = append(roots, root)
+		}
+		t.messages = append(t.messages, message)
+	}
+
+	sort.Slice(roots, func(i, j int) bool { return tsLess(roots[i], roots[j]) })
+
+	var b strings.Builder
+	if len(channels) == 1 && messages[0].ChannelName != "" {
+		b.WriteString("# " + messages[0].ChannelName + "\n")
+	}
+
+	day := ""
+	for _, root := range roots {
+		t := threads[root]
+		sort.SliceStable(t.messages, func(i, j int) bool { return tsLess(t.messages[i].Time, t.messages[j].Time) })
+
+		if d := tsTime(root, loc).Format("2006-01-02 (Monday)"); d != day {
+			day = d
+			b.WriteString("\n## " + day + "\n\n")
+		}
+
+		for i, message := range t.messages {
+			// Replies are indented below the first message of the thread,
+			// which is the parent unless it is not part of the result.
+			indent := ""
+			if i > 0 {
+				indent = "  "
+			}
+			writeTranscriptLine(&b, indent, message, len(channels) > 1, loc)
+		}
+	}
+
+	if cursor != "" {
+		b.WriteString("\nMore messages are available, continue with cursor `" + cursor + "`.\n")
+	}
+
+	return b.String()
+}
+
+func writeTranscriptLine(b *strings.Builder, indent string, message Message, withChannel bool, loc *time.Location) {
+	author := message.UserName
+	if author == "" {
+		author = message.RealName
+	}
+	if author == "" {
+		author = message.UserID
+	}
+
+	b.WriteString(indent + "- **" + author + "** " + tsTime(message.Time, loc).Format("15:04"))
+	if withChannel && message.ChannelName != "" {
+		b.WriteString(" in " + message.ChannelName)
+	}
+	b.WriteString(": ")
+
+	lines := strings.Split(strings.TrimSpace(message.Text), "\n")
+	b.WriteString(lines[0])
+	for _, line := range lines[1:] {
+		b.WriteString("\n" + indent + "  " + line)
+	}
+
+	if len(message.FileItems) > 0 {
+		var names []string
+		for _, file := range message.FileItems {
+			names = append(names, file.Name)
+		}
+		b.WriteString(" [files: " + strings.Join(names, ", ") + "]")
+	}
+	if message.ReplyCount > 0 {
+		fmt.Fprintf(b, " _(%d replies)_", message.ReplyCount)
+	}
+	if len(message.ReactionItems) > 0 {
+		var reactions []string
+		for _, reaction := range message.ReactionItems {
+			reactions = append(reactions, ":"+reaction.Name+": "+strconv.Itoa(reaction.Count))
+		}
+		b.WriteString(" [" + strings.Join(reactions, ", ") + "]")
+	}
+	if message.Permalink != "" {
+		b.WriteString(" ([link](" + message.Permalink + "))")
+	}
+	b.WriteString("\n")
+}
+
+// tsTime converts a Slack timestamp to a time in loc.
+func tsTime(ts string, loc *time.Location) time.Time {
+	sec, frac, _ := strings.Cut(ts, ".")
+	s, _ := strconv.ParseInt(sec, 10, 64)
+	us, _ := strconv.ParseInt((frac + "000000")[:6], 10, 64)
+
+	return time.Unix(s, us*1000).In(loc)
+}
+
+func tsLess(a, b string) bool {
+	return tsTime(a, time.UTC).Before(tsTime(b, time.UTC))
+}
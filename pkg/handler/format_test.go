@@ -0,0 +1,102 @@
+package handler
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/slack-go/slack"
+)
+
+func resultText(t *testing.T, result *mcp.CallToolResult) string {
+	t.Helper()
+
+	text, ok := result.Content[0].(mcp.TextContent)
+	if !ok {
+		t.Fatalf("content is %T, want text", result.Content[0])
+	}
+	return text.Text
+}
+
+func TestRowsResult(t *testing.T) {
+	rows := []Workspace{
+		{Name: "acme", Default: true, Status: "ready"},
+		{Name: "partner|ext", Status: "failed"},
+	}
+
+	tests := []struct {
+		format Format
+		want   string
+	}{
+		{FormatCSV, "Name,Default,Team,User,AuthMode,Status\nacme,true,,,,ready\npartner|ext,false,,,,failed\n"},
+		{FormatJSON, `[{"name":"acme","default":true,"team":"","user":"","authMode":"","status":"ready"},{"name":"partner|ext","default":false,"team":"","user":"","authMode":"","status":"failed"}]`},
+		{FormatJSONL, "{\"name\":\"acme\",\"default\":true,\"team\":\"\",\"user\":\"\",\"authMode\":\"\",\"status\":\"ready\"}\n{\"name\":\"partner|ext\",\"default\":false,\"team\":\"\",\"user\":\"\",\"authMode\":\"\",\"status\":\"failed\"}\n"},
+		{FormatMarkdown, "| Name | Default | Team | User | AuthMode | Status |\n| --- | --- | --- | --- | --- | --- |\n| acme | true |  |  |  | ready |\n| partner\\|ext | false |  |  |  | failed |\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.format), func(t *testing.T) {
+			result, err := rowsResult(rows, tt.format)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := resultText(t, result); got != tt.want {
+				t.Errorf("rowsResult() =\n%s\nwant\n%s", got, tt.want)
+			}
+			structured, _ := json.Marshal(result.StructuredContent)
+			if want := `{"rows":` + tests[1].want + `}`; string(structured) != want {
+				t.Errorf("rowsResult() structured content = %s, want %s", structured, want)
+			}
+		})
+	}
+
+	result, err := rowsResult([]Workspace(nil), FormatJSON)
+	if err != nil || resultText(t, result) != "[]" {
+		t.Errorf("empty JSON result = %v, %v", result, err)
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	for in, want := range map[string]Format{"": FormatCSV, "JSON": FormatJSON, "jsonl": FormatJSONL, "md": FormatMarkdown} {
+		if got, err := ParseFormat(in); err != nil || got != want {
+			t.Errorf("ParseFormat(%q) = %q, %v, want %q", in, got, err, want)
+		}
+	}
+	if _, err := ParseFormat("xml"); err == nil {
+		t.Error("ParseFormat(xml) accepted")
+	}
+}
+
+func TestMarkdownTranscript(t *testing.T) {
+	// History order: newest first, replies of the first thread included.
+	messages := []Message{
+		{UserName: "carol", ChannelName: "#general", Channel: "C1", Time: "1756800000.000000", Text: "next day", ReactionItems: []slack.ItemReaction{
+			{Name: "eyes", Count: 2}, {Name: "tada", Count: 1}, {Name: "+1::skin-tone-2", Count: 3},
+		}, FileItems: []slack.File{{ID: "F1", Name: "notes: q3.txt"}}},
+		{UserName: "bob", ChannelName: "#general", Channel: "C1", Time: "1756720000.000000", ThreadTs: "1756710000.000000", Text: "reply"},
+		{UserName: "alice", ChannelName: "#general", Channel: "C1", Time: "1756710000.000000", ThreadTs: "1756710000.000000", ReplyCount: 1, Text: "first line\nsecond line", Cursor: "range::1756710000.000000"},
+	}
+
+	got := markdownTranscript(messages, time.UTC)
+	want := strings.Join([]string{
+		"# #general",
+		"",
+		"## 2025-09-01 (Monday)",
+		"",
+		"- **alice** 07:00: first line",
+		"  second line _(1 replies)_",
+		"  - **bob** 09:46: reply",
+		"",
+		"## 2025-09-02 (Tuesday)",
+		"",
+		"- **carol** 08:00: next day [files: notes: q3.txt] [:eyes: 2, :tada: 1, :+1::skin-tone-2: 3]",
+		"",
+		"More messages are available, continue with cursor `range::1756710000.000000`.",
+		"",
+	}, "\n")
+	if got != want {
+		t.Errorf("markdownTranscript() =\n%s\nwant\n%s", got, want)
+	}
+}
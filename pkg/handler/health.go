@@ -0,0 +1,77 @@
+package handler
+
+import (
+	"context"
+	"time"
+
+	"github.com/korotovsky/slack-mcp-server/pkg/provider"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+type Health struct {
+	Workspace   string `json:"workspace"`
+	Status      string `json:"status"`
+	AuthMode    string `json:"authMode"`
+	Error       string `json:"error"`
+	Team        string `json:"team"`
+	User        string `json:"user"`
+	Users       int    `json:"users"`
+	LastAttempt string `json:"lastAttempt"`
+	BootedAt    string `json:"bootedAt"`
+	Requests    int64  `json:"requests"`
+	Retries     int64  `json:"retries"`
+	RateLimited int64  `json:"rateLimited"`
+	BudgetWaits int64  `json:"budgetWaits"`
+}
+
+type HealthHandler struct {
+	workspaces *provider.Workspaces
+	format     Format
+}
+
+func NewHealthHandler(workspaces *provider.Workspaces) *HealthHandler {
+	return &HealthHandler{
+		workspaces: workspaces,
+		format:     formatFromEnv(),
+	}
+}
+
+func (hh *HealthHandler) HealthHandler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	format, err := requestFormat(request, hh.format)
+	if err != nil {
+		return nil, err
+	}
+
+	apiProvider, err := hh.workspaces.Provider(request.GetString("workspace", ""))
+	if err != nil {
+		return nil, err
+	}
+
+	h := apiProvider.Health()
+
+	healthList := []Health{{
+		Workspace:   apiProvider.Name(),
+		Status:      h.Status,
+		AuthMode:    h.AuthMode,
+		Error:       h.Error,
+		Team:        h.Team,
+		User:        h.User,
+		Users:       h.Users,
+		LastAttempt: formatTime(h.LastAttempt),
+		BootedAt:    formatTime(h.BootedAt),
+		Requests:    h.Requests,
+		Retries:     h.Retries,
+		RateLimited: h.RateLimited,
+		BudgetWaits: h.BudgetWaits,
+	}}
+
+	return rowsResult(healthList, format)
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+
+	return t.UTC().Format(time.RFC3339)
+}
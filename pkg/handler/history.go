@@ -0,0 +1,196 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/korotovsky/slack-mcp-server/pkg/provider"
+	"github.com/korotovsky/slack-mcp-server/pkg/text"
+	"github.com/slack-go/slack"
+)
+
+const (
+	defaultHistoryMaxMessages = 500
+
+	// historyPageSize is the number of messages requested per page when a
+	// time range is fetched.
+	historyPageSize = 100
+)
+
+// historyBudget bounds how much of a time range conversations_history
+// returns in a single call. It is configured by
+// SLACK_MCP_HISTORY_MAX_MESSAGES and SLACK_MCP_HISTORY_MAX_TOKENS; a zero
+// token budget disables the token limit.
+type historyBudget struct {
+	maxMessages int
+	maxTokens   int
+
+	// limit is the number of messages the caller asked for, if any. It is
+	// carried in range cursors so that later pages keep the same size.
+	limit int
+}
+
+// withLimit caps the budget at a number of messages requested by the
+// caller. A zero limit leaves the budget unchanged.
+func (b historyBudget) withLimit(limit int) historyBudget {
+	if limit > 0 {
+		b.maxMessages = min(limit, b.maxMessages)
+		b.limit = limit
+	}
+	return b
+}
+
+func historyBudgetFromEnv() historyBudget {
+	return historyBudget{
+		maxMessages: intFromEnv("SLACK_MCP_HISTORY_MAX_MESSAGES", defaultHistoryMaxMessages, 1),
+		maxTokens:   intFromEnv("SLACK_MCP_HISTORY_MAX_TOKENS", 0, 0),
+	}
+}
+
+// intFromEnv parses an integer of at least min from the named environment
+// variable.
+func intFromEnv(name string, fallback, min int) int {
+	value := os.Getenv(name)
+	if value == "" {
+		return fallback
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil || n < min {
+		log.Printf("Invalid %s %q, using default %d", name, value, fallback)
+		return fallback
+	}
+
+	return n
+}
+
+// rangeCursor continues a time range that did not fit into the history
+// budget. Messages are returned newest first, so the range continues
+// below the oldest message returned so far. It is encoded as
+// "range:<oldest>:<latest>" with Slack timestamps, followed by
+// ":<limit>" when the caller asked for a number of messages.
+type rangeCursor struct {
+	oldest string
+	latest string
+	limit  int
+}
+
+func (c rangeCursor) String() string {
+	s := "range:" + c.oldest + ":" + c.latest
+	if c.limit > 0 {
+		s += ":" + strconv.Itoa(c.limit)
+	}
+	return s
+}
+
+func parseRangeCursor(cursor string) (rangeCursor, bool) {
+	value, ok := strings.CutPrefix(cursor, "range:")
+	if !ok {
+		return rangeCursor{}, false
+	}
+
+	parts := strings.Split(value, ":")
+	if len(parts) != 2 && len(parts) != 3 {
+		return rangeCursor{}, false
+	}
+	c := rangeCursor{oldest: parts[0], latest: parts[1]}
+	if c.latest == "" || (c.oldest != "" && !slackTsRe.MatchString(c.oldest)) || !slackTsRe.MatchString(c.latest) {
+		return rangeCursor{}, false
+	}
+	if len(parts) == 3 {
+		limit, err := strconv.Atoi(parts[2])
+		if err != nil || limit <= 0 {
+			return rangeCursor{}, false
+		}
+		c.limit = limit
+	}
+
+	return c, true
+}
+
+// fetchHistoryRange follows the pages of conversations.history until the
+// time range of params is covered or the history budget, capped at limit
+// messages if set, is used up. In
+// the latter case the last message carries a range cursor to continue
+// from.
+func (ch *ConversationsHandler) fetchHistoryRange(ctx context.Context, api *slack.Client, apiProvider *provider.ApiProvider, params *slack.GetConversationHistoryParameters, textOpts text.Options, limit int) ([]Message, error) {
+	return collectHistory(
+		func(params *slack.GetConversationHistoryParameters) (*slack.GetConversationHistoryResponse, error) {
+			return api.GetConversationHistoryContext(ctx, params)
+		},
+		func(messages []slack.Message) []Message {
+			return convertMessages(ctx, apiProvider, messages, params.ChannelID, textOpts)
+		},
+		params,
+		ch.budget.withLimit(limit),
+	)
+}
+
+func collectHistory(
+	fetch func(*slack.GetConversationHistoryParameters) (*slack.GetConversationHistoryResponse, error),
+	convert func([]slack.Message) []Message,
+	params *slack.GetConversationHistoryParameters,
+	budget historyBudget,
+) ([]Message, error) {
+	var (
+		messageList []Message
+		tokens      int
+		truncated   bool
+	)
+	for {
+		params.Limit = min(historyPageSize, budget.maxMessages-len(messageList))
+
+		history, err := fetch(params)
+		if err != nil {
+			if len(messageList) == 0 {
+				return nil, err
+			}
+			// Return what was fetched, the cursor resumes at the failed page.
+			log.Printf("History fetch of %s failed after %d messages: %v", params.ChannelID, len(messageList), err)
+			truncated = true
+			break
+		}
+
+		for _, message := range convert(history.Messages) {
+			cost := estimateTokens(message)
+			if len(messageList) >= budget.maxMessages || (budget.maxTokens > 0 && len(messageList) > 0 && tokens+cost > budget.maxTokens) {
+				truncated = true
+				break
+			}
+			messageList = append(messageList, message)
+			tokens += cost
+		}
+
+		if truncated || !history.HasMore {
+			break
+		}
+		if len(messageList) >= budget.maxMessages {
+			truncated = true
+			break
+		}
+		params.Cursor = history.ResponseMetaData.NextCursor
+	}
+
+	if truncated && len(messageList) > 0 {
+		last := &messageList[len(messageList)-1]
+		last.Cursor = rangeCursor{oldest: params.Oldest, latest: last.Time, limit: budget.limit}.String()
+		log.Printf("History of %s truncated after %d messages (~%d tokens)", params.ChannelID, len(messageList), tokens)
+	}
+
+	return messageList, nil
+}
+
+// estimateTokens roughly estimates the tokens a message takes in the CSV
+// output, assuming four characters per token.
+func estimateTokens(message Message) int {
+	chars := len(message.UserID) + len(message.UserName) + len(message.RealName) +
+		len(message.Channel) + len(message.ChannelName) + len(message.ThreadTs) +
+		len(message.Text) + len(message.Time) + len(fmt.Sprint(message.ReplyCount)) +
+		len(message.Reactions) + len(message.Files) + 10
+
+	return chars/4 + 1
+}
@@ -0,0 +1,115 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/slack-go/slack"
+)
+
+// pagedHistory serves total messages newest first in pages, failing on the
+// page starting at failAt when it is positive.
+func pagedHistory(total, failAt int) (func(*slack.GetConversationHistoryParameters) (*slack.GetConversationHistoryResponse, error), *int) {
+	calls := new(int)
+	return func(params *slack.GetConversationHistoryParameters) (*slack.GetConversationHistoryResponse, error) {
+		*calls++
+		offset := 0
+		fmt.Sscanf(params.Cursor, "%d", &offset)
+		if failAt > 0 && offset == failAt {
+			return nil, errors.New("internal_error")
+		}
+
+		resp := &slack.GetConversationHistoryResponse{}
+		for i := offset; i < total && i < offset+params.Limit; i++ {
+			resp.Messages = append(resp.Messages, slack.Message{Msg: slack.Msg{
+				Timestamp: fmt.Sprintf("%d.000000", 1700000000-i),
+				Text:      strings.Repeat("x", 36),
+			}})
+		}
+		end := offset + len(resp.Messages)
+		resp.HasMore = end < total
+		resp.ResponseMetaData.NextCursor = fmt.Sprint(end)
+		return resp, nil
+	}, calls
+}
+
+func convertPlain(messages []slack.Message) []Message {
+	var messageList []Message
+	for _, message := range messages {
+		messageList = append(messageList, Message{Text: message.Text, Time: message.Timestamp})
+	}
+	return messageList
+}
+
+func TestCollectHistory(t *testing.T) {
+	tests := []struct {
+		name       string
+		total      int
+		failAt     int
+		budget     historyBudget
+		wantCount  int
+		wantCalls  int
+		wantCursor string
+		wantErr    bool
+	}{
+		{name: "Range covered", total: 250, budget: historyBudget{maxMessages: 500}, wantCount: 250, wantCalls: 3},
+		{name: "Message budget", total: 250, budget: historyBudget{maxMessages: 120}, wantCount: 120, wantCalls: 2, wantCursor: "range:1699990000.000000:1699999881.000000"},
+		{name: "Explicit limit", total: 250, budget: historyBudget{maxMessages: 500}.withLimit(20), wantCount: 20, wantCalls: 1, wantCursor: "range:1699990000.000000:1699999981.000000:20"},
+		{name: "Explicit limit above budget", total: 250, budget: historyBudget{maxMessages: 120}.withLimit(200), wantCount: 120, wantCalls: 2, wantCursor: "range:1699990000.000000:1699999881.000000:200"},
+		{name: "Budget equals range", total: 200, budget: historyBudget{maxMessages: 200}, wantCount: 200, wantCalls: 2},
+		{name: "Token budget", total: 250, budget: historyBudget{maxMessages: 500, maxTokens: 150}, wantCount: 8, wantCalls: 1, wantCursor: "range:1699990000.000000:1699999993.000000"},
+		{name: "Failure after first page", total: 250, failAt: 100, budget: historyBudget{maxMessages: 500}, wantCount: 100, wantCalls: 2, wantCursor: "range:1699990000.000000:1699999901.000000"},
+		{name: "Failure on first page", total: 250, failAt: -1, budget: historyBudget{maxMessages: 500}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fetch, calls := pagedHistory(tt.total, tt.failAt)
+			if tt.failAt < 0 {
+				fetch = func(*slack.GetConversationHistoryParameters) (*slack.GetConversationHistoryResponse, error) {
+					*calls++
+					return nil, errors.New("channel_not_found")
+				}
+			}
+			params := &slack.GetConversationHistoryParameters{Oldest: "1699990000.000000"}
+
+			messages, err := collectHistory(fetch, convertPlain, params, tt.budget)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("collectHistory() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(messages) != tt.wantCount {
+				t.Errorf("got %d messages, want %d", len(messages), tt.wantCount)
+			}
+			if !tt.wantErr && *calls != tt.wantCalls {
+				t.Errorf("got %d calls, want %d", *calls, tt.wantCalls)
+			}
+			cursor := ""
+			if len(messages) > 0 {
+				cursor = messages[len(messages)-1].Cursor
+			}
+			if cursor != tt.wantCursor {
+				t.Errorf("cursor = %q, want %q", cursor, tt.wantCursor)
+			}
+		})
+	}
+}
+
+func TestParseRangeCursor(t *testing.T) {
+	c, ok := parseRangeCursor(rangeCursor{oldest: "1699990000.000000", latest: "1700000000.000100"}.String())
+	if !ok || c.oldest != "1699990000.000000" || c.latest != "1700000000.000100" {
+		t.Errorf("round trip = %+v, %v", c, ok)
+	}
+
+	c, ok = parseRangeCursor(rangeCursor{oldest: "", latest: "1700000000.000100", limit: 20}.String())
+	if !ok || c.oldest != "" || c.latest != "1700000000.000100" || c.limit != 20 {
+		t.Errorf("round trip with limit = %+v, %v", c, ok)
+	}
+
+	for _, cursor := range []string{"bmV4dF90czoxNzAw", "range:", "range:abc:1700000000.000100", "range:1699990000.000000:", "range::1700000000.000100:0", "range::1700000000.000100:20:1"} {
+		if _, ok := parseRangeCursor(cursor); ok {
+			t.Errorf("parseRangeCursor(%q) accepted", cursor)
+		}
+	}
+}
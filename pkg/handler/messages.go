@@ -0,0 +1,214 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/korotovsky/slack-mcp-server/pkg/provider"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/slack-go/slack"
+)
+
+const (
+	// maxMessageContext caps the number of messages around the requested
+	// one that message_get returns on each side.
+	maxMessageContext = 50
+
+	// maxThreadMessages caps the number of thread messages fetched.
+	maxThreadMessages = 1000
+)
+
+// MessageGetHandler returns the message a permalink points to, optionally
+// surrounded by the messages before and after it and followed by its
+// thread. Messages are ordered oldest first and the requested message is
+// the only row with its permalink set.
+func (ch *ConversationsHandler) MessageGetHandler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	link := request.GetString("permalink", "")
+	if link == "" {
+		return nil, errors.New("permalink must be a string")
+	}
+
+	permalink, ok := provider.ParsePermalink(link)
+	if !ok || permalink.Ts == "" {
+		return nil, fmt.Errorf("invalid permalink %q: expected a Slack message link such as https://team.slack.com/archives/C0123456789/p1700000000123456", link)
+	}
+
+	before := request.GetInt("context_before", 0)
+	after := request.GetInt("context_after", 0)
+	if before < 0 || before > maxMessageContext || after < 0 || after > maxMessageContext {
+		return nil, fmt.Errorf("context_before and context_after must be between 0 and %d", maxMessageContext)
+	}
+	includeThread := request.GetBool("include_thread", false)
+
+	textOpts, err := ch.requestTextOptions(request)
+	if err != nil {
+		return nil, err
+	}
+
+	format, err := requestFormat(request, ch.format)
+	if err != nil {
+		return nil, err
+	}
+
+	apiProvider, err := ch.workspaces.Provider(request.GetString("workspace", ""))
+	if err != nil {
+		return nil, err
+	}
+
+	api, err := apiProvider.Provide()
+	if err != nil {
+		return nil, err
+	}
+
+	var messages []slack.Message
+	isReply := permalink.ThreadTs != "" && permalink.ThreadTs != permalink.Ts
+	if isReply {
+		// The context of a reply is the rest of its thread.
+		thread, err := fetchThread(ctx, api, permalink.Channel, permalink.ThreadTs)
+		if err != nil {
+			return nil, err
+		}
+		if includeThread {
+			messages = thread
+		} else {
+			messages = messagesAround(thread, permalink.Ts, before, after)
+		}
+	} else {
+		messages, err = fetchAround(ctx, api, permalink.Channel, permalink.Ts, before, after)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	target := -1
+	for i, message := range messages {
+		if message.Timestamp == permalink.Ts {
+			target = i
+			break
+		}
+	}
+	if target < 0 {
+		return nil, fmt.Errorf("message %s not found in channel %s", permalink.Ts, permalink.Channel)
+	}
+
+	if includeThread && !isReply && messages[target].ReplyCount > 0 {
+		thread, err := fetchThread(ctx, api, permalink.Channel, permalink.Ts)
+		if err != nil {
+			return nil, err
+		}
+		// The parent is already part of the result, append the replies
+		// right after it.
+		var replies []slack.Message
+		for _, message := range thread {
+			if message.Timestamp != permalink.Ts {
+				replies = append(replies, message)
+			}
+		}
+		messages = append(messages[:target+1], append(replies, messages[target+1:]...)...)
+	}
+
+	messageList := convertMessages(ctx, apiProvider, messages, permalink.Channel, textOpts)
+	for i := range messageList {
+		if messageList[i].Time == permalink.Ts {
+			messageList[i].Permalink = link
+		}
+	}
+
+	return messagesResult(messageList, format, ch.location)
+}
+
+// fetchAround returns the channel message with timestamp ts together with
+// up to before and after channel messages around it, oldest first.
+func fetchAround(ctx context.Context, api *slack.Client, channel, ts string, before, after int) ([]slack.Message, error) {
+	older, err := api.GetConversationHistoryContext(ctx, &slack.GetConversationHistoryParameters{
+		ChannelID: channel,
+		Latest:    ts,
+		Inclusive: true,
+		Limit:     before + 1,
+	})
+	if err != nil {
+		return nil, err
+	}
+	messages := older.Messages
+
+	if after > 0 {
+		// With only oldest set, Slack returns the messages right after it.
+		newer, err := api.GetConversationHistoryContext(ctx, &slack.GetConversationHistoryParameters{
+			ChannelID: channel,
+			Oldest:    ts,
+			Limit:     after,
+		})
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, newer.Messages...)
+	}
+
+	sortMessages(messages)
+
+	return messagesAround(messages, ts, before, after), nil
+}
+
+// fetchThread returns the parent and the replies of a thread, oldest
+// first.
+func fetchThread(ctx context.Context, api *slack.Client, channel, threadTs string) ([]slack.Message, error) {
+	params := &slack.GetConversationRepliesParameters{
+		ChannelID: channel,
+		Timestamp: threadTs,
+		Limit:     200,
+		Inclusive: true,
+	}
+
+	var thread []slack.Message
+	for {
+		messages, hasMore, nextCursor, err := api.GetConversationRepliesContext(ctx, params)
+		if err != nil {
+			return nil, err
+		}
+		for _, message := range messages {
+			// Every page starts with the parent message.
+			if len(thread) > 0 && message.Timestamp == threadTs {
+				continue
+			}
+			thread = append(thread, message)
+		}
+
+		if !hasMore || nextCursor == "" || len(thread) >= maxThreadMessages {
+			break
+		}
+		params.Cursor = nextCursor
+	}
+
+	sortMessages(thread)
+
+	return thread, nil
+}
+
+// messagesAround returns the message with timestamp ts and up to before and
+// after messages around it from messages sorted oldest first. It returns
+// nil when the message is not found.
+func messagesAround(messages []slack.Message, ts string, before, after int) []slack.Message {
+	for i, message := range messages {
+		if message.Timestamp != ts {
+			continue
+		}
+		return messages[max(0, i-before):min(len(messages), i+after+1)]
+	}
+
+	return nil
+}
+
+// sortMessages orders messages oldest first. Slack timestamps have a fixed
+// number of decimals, so comparing their lengths first orders them
+// numerically.
+func sortMessages(messages []slack.Message) {
+	sort.SliceStable(messages, func(i, j int) bool {
+		a, b := messages[i].Timestamp, messages[j].Timestamp
+		if len(a) != len(b) {
+			return len(a) < len(b)
+		}
+		return a < b
+	})
+}
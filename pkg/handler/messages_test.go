@@ -0,0 +1,109 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/slack-go/slack"
+)
+
+// fakeHistory serves conversations.history and conversations.replies for a
+// channel with messages at ts 1700000001.000000 to 1700000009.000000, where
+// the fifth message has two replies.
+func fakeHistory(t *testing.T) *slack.Client {
+	t.Helper()
+
+	ts := func(i int) string { return fmt.Sprintf("170000000%d.000000", i) }
+	message := func(ts, threadTs string, replies int) map[string]any {
+		return map[string]any{"type": "message", "ts": ts, "thread_ts": threadTs, "reply_count": replies, "text": "m" + ts}
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		w.Header().Set("Content-Type", "application/json")
+
+		var messages []map[string]any
+		switch r.URL.Path {
+		case "/conversations.history":
+			limit, _ := strconv.Atoi(r.Form.Get("limit"))
+			latest, oldest := r.Form.Get("latest"), r.Form.Get("oldest")
+			inclusive := r.Form.Get("inclusive") == "1"
+			if latest != "" {
+				// Newest first, starting at latest.
+				for i := 9; i >= 1 && len(messages) < limit; i-- {
+					if ts(i) < latest || (inclusive && ts(i) == latest) {
+						messages = append(messages, message(ts(i), ts(i), 0))
+					}
+				}
+			} else {
+				// Only oldest: the messages right after it, newest first.
+				var newer []map[string]any
+				for i := 1; i <= 9 && len(newer) < limit; i++ {
+					if ts(i) > oldest {
+						newer = append([]map[string]any{message(ts(i), "", 0)}, newer...)
+					}
+				}
+				messages = newer
+			}
+		case "/conversations.replies":
+			messages = []map[string]any{
+				message(ts(5), ts(5), 2),
+				message("1700000005.000100", ts(5), 0),
+				message("1700000005.000200", ts(5), 0),
+			}
+		}
+		json.NewEncoder(w).Encode(map[string]any{"ok": true, "messages": messages})
+	}))
+	t.Cleanup(srv.Close)
+
+	return slack.New("xoxb-test", slack.OptionAPIURL(srv.URL+"/"))
+}
+
+func timestamps(messages []slack.Message) []string {
+	var ts []string
+	for _, message := range messages {
+		ts = append(ts, message.Timestamp)
+	}
+	return ts
+}
+
+func TestFetchAround(t *testing.T) {
+	api := fakeHistory(t)
+
+	messages, err := fetchAround(context.Background(), api, "C0123456789", "1700000005.000000", 2, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"1700000003.000000", "1700000004.000000", "1700000005.000000", "1700000006.000000"}
+	if got := timestamps(messages); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("fetchAround() = %v, want %v", got, want)
+	}
+}
+
+func TestFetchThread(t *testing.T) {
+	api := fakeHistory(t)
+
+	thread, err := fetchThread(context.Background(), api, "C0123456789", "1700000005.000000")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"1700000005.000000", "1700000005.000100", "1700000005.000200"}
+	if got := timestamps(thread); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("fetchThread() = %v, want %v", got, want)
+	}
+
+	around := messagesAround(thread, "1700000005.000200", 1, 5)
+	if got := timestamps(around); fmt.Sprint(got) != fmt.Sprint(want[1:]) {
+		t.Errorf("messagesAround() = %v, want %v", got, want[1:])
+	}
+	if messagesAround(thread, "1700000006.000000", 1, 1) != nil {
+		t.Error("messagesAround() of a missing message must be nil")
+	}
+}
@@ -0,0 +1,166 @@
+package handler
+
+import (
+	"context"
+	"errors"
+
+	"github.com/korotovsky/slack-mcp-server/pkg/provider"
+	"github.com/korotovsky/slack-mcp-server/pkg/text"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/slack-go/slack"
+)
+
+type Bookmark struct {
+	ID            string `json:"id"`
+	Title         string `json:"title"`
+	Link          string `json:"link"`
+	Emoji         string `json:"emoji"`
+	Type          string `json:"type"`
+	Created       string `json:"created"`
+	UpdatedByID   string `json:"updatedByID"`
+	UpdatedByName string `json:"updatedByName"`
+}
+
+// PinsListHandler returns the items pinned to a channel as messages with
+// their permalinks. Pinned files are returned as rows with the file in
+// the files column.
+func (ch *ConversationsHandler) PinsListHandler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	channel := request.GetString("channel_id", "")
+	if channel == "" {
+		return nil, errors.New("channel_id must be a string")
+	}
+
+	textOpts, err := ch.requestTextOptions(request)
+	if err != nil {
+		return nil, err
+	}
+
+	format, err := requestFormat(request, ch.format)
+	if err != nil {
+		return nil, err
+	}
+
+	apiProvider, err := ch.workspaces.Provider(request.GetString("workspace", ""))
+	if err != nil {
+		return nil, err
+	}
+
+	api, err := apiProvider.Provide()
+	if err != nil {
+		return nil, err
+	}
+
+	channel, err = apiProvider.ResolveChannel(ctx, channel)
+	if err != nil {
+		return nil, err
+	}
+
+	messageList, err := fetchPins(ctx, api, apiProvider, channel, textOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	return messagesResult(messageList, format, ch.location)
+}
+
+func (ch *ChannelsHandler) BookmarksListHandler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	channel := request.GetString("channel_id", "")
+	if channel == "" {
+		return nil, errors.New("channel_id must be a string")
+	}
+
+	format, err := requestFormat(request, ch.format)
+	if err != nil {
+		return nil, err
+	}
+
+	apiProvider, err := ch.workspaces.Provider(request.GetString("workspace", ""))
+	if err != nil {
+		return nil, err
+	}
+
+	api, err := apiProvider.Provide()
+	if err != nil {
+		return nil, err
+	}
+
+	channel, err = apiProvider.ResolveChannel(ctx, channel)
+	if err != nil {
+		return nil, err
+	}
+
+	bookmarkList, err := fetchBookmarks(ctx, api, apiProvider, channel)
+	if err != nil {
+		return nil, err
+	}
+
+	return rowsResult(bookmarkList, format)
+}
+
+// fetchPins returns the pinned messages and files of a channel in the
+// order Slack returns them, most recently pinned first.
+func fetchPins(ctx context.Context, api *slack.Client, apiProvider *provider.ApiProvider, channel string, textOpts text.Options) ([]Message, error) {
+	items, _, err := api.ListPinsContext(ctx, channel)
+	if err != nil {
+		return nil, err
+	}
+
+	messages := pinnedMessages(items)
+	messageList := convertMessages(ctx, apiProvider, messages, channel, textOpts)
+	for i, message := range messages {
+		messageList[i].Permalink = message.Permalink
+	}
+
+	return messageList, nil
+}
+
+func fetchBookmarks(ctx context.Context, api *slack.Client, apiProvider *provider.ApiProvider, channel string) ([]Bookmark, error) {
+	bookmarks, err := api.ListBookmarksContext(ctx, channel)
+	if err != nil {
+		return nil, err
+	}
+
+	usersMap := apiProvider.ProvideUsersMap()
+	bookmarkList := make([]Bookmark, 0, len(bookmarks))
+	for _, bookmark := range bookmarks {
+		updatedBy := ""
+		if user, ok := usersMap[bookmark.LastUpdatedByUserID]; ok {
+			updatedBy = user.Name
+		}
+
+		bookmarkList = append(bookmarkList, Bookmark{
+			ID:            bookmark.ID,
+			Title:         bookmark.Title,
+			Link:          bookmark.Link,
+			Emoji:         bookmark.Emoji,
+			Type:          bookmark.Type,
+			Created:       formatTs(bookmark.Created.Time()),
+			UpdatedByID:   bookmark.LastUpdatedByUserID,
+			UpdatedByName: updatedBy,
+		})
+	}
+
+	return bookmarkList, nil
+}
+
+// pinnedMessages returns pinned items as messages with their permalinks. A
+// pinned file is shown as if it was posted by its uploader.
+func pinnedMessages(items []slack.Item) []slack.Message {
+	messages := make([]slack.Message, 0, len(items))
+	for _, item := range items {
+		switch {
+		case item.Message != nil:
+			messages = append(messages, *item.Message)
+		case item.File != nil:
+			var message slack.Message
+			message.User = item.File.User
+			message.Text = item.File.Title
+			message.Timestamp = formatTs(item.File.Created.Time())
+			message.Permalink = item.File.Permalink
+			message.Files = []slack.File{*item.File}
+			messages = append(messages, message)
+		}
+	}
+
+	return messages
+}
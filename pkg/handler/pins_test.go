@@ -0,0 +1,33 @@
+package handler
+
+import (
+	"testing"
+
+	"github.com/slack-go/slack"
+)
+
+func TestPinnedMessages(t *testing.T) {
+	pinned := slack.Message{}
+	pinned.Timestamp = "1700000000.000100"
+	pinned.Text = "Runbook: restart the worker with make restart"
+	pinned.Permalink = "https://team.slack.com/archives/C1/p1700000000000100"
+
+	items := []slack.Item{
+		{Type: "message", Channel: "C1", Message: &pinned},
+		{Type: "file", File: &slack.File{ID: "F1", Title: "Architecture", User: "U1", Created: 1700000100, Permalink: "https://team.slack.com/files/U1/F1/arch.png"}},
+		{Type: "file_comment"},
+	}
+
+	messages := pinnedMessages(items)
+	if len(messages) != 2 {
+		t.Fatalf("pinnedMessages() returned %d messages, want 2", len(messages))
+	}
+	if messages[0].Text != pinned.Text || messages[0].Permalink != pinned.Permalink {
+		t.Errorf("pinned message = %+v", messages[0])
+	}
+	file := messages[1]
+	if file.User != "U1" || file.Text != "Architecture" || file.Timestamp != "1700000100.000000" ||
+		len(file.Files) != 1 || file.Permalink != "https://team.slack.com/files/U1/F1/arch.png" {
+		t.Errorf("pinned file = %+v", file)
+	}
+}
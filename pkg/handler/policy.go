@@ -0,0 +1,109 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/korotovsky/slack-mcp-server/pkg/provider"
+)
+
+// WritePolicy decides whether tools that modify Slack state are allowed to
+// act on a given channel. It is configured by SLACK_MCP_ADD_MESSAGE_TOOL:
+//   - empty or unset: all write tools are disabled,
+//   - "true" or "1": writes are allowed to every channel,
+//   - comma-separated channel IDs: writes are allowed only to those channels,
+//   - comma-separated channel IDs prefixed with "!": writes are allowed to
+//     every channel except those.
+type WritePolicy struct {
+	enabled bool
+	allow   map[string]bool
+	deny    map[string]bool
+}
+
+func NewWritePolicyFromEnv() *WritePolicy {
+	return NewWritePolicy(os.Getenv("SLACK_MCP_ADD_MESSAGE_TOOL"))
+}
+
+func NewWritePolicy(config string) *WritePolicy {
+	config = strings.TrimSpace(config)
+	if config == "" || config == "false" || config == "0" {
+		return &WritePolicy{}
+	}
+
+	policy := &WritePolicy{
+		enabled: true,
+		allow:   make(map[string]bool),
+		deny:    make(map[string]bool),
+	}
+	if config == "true" || config == "1" {
+		return policy
+	}
+
+	for _, item := range strings.Split(config, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		if strings.HasPrefix(item, "!") {
+			policy.deny[strings.TrimPrefix(item, "!")] = true
+		} else {
+			policy.allow[item] = true
+		}
+	}
+
+	return policy
+}
+
+// Enabled returns an error if write tools are disabled.
+func (p *WritePolicy) Enabled() error {
+	if !p.enabled {
+		return fmt.Errorf("write tools are disabled by default, set SLACK_MCP_ADD_MESSAGE_TOOL to 'true' or to a comma-separated list of allowed channel IDs to enable them")
+	}
+
+	return nil
+}
+
+// Check returns an error if writing to the channel is not permitted.
+func (p *WritePolicy) Check(channel string) error {
+	if err := p.Enabled(); err != nil {
+		return err
+	}
+	if p.deny[channel] {
+		return fmt.Errorf("writing to channel %q is not allowed by SLACK_MCP_ADD_MESSAGE_TOOL", channel)
+	}
+	if len(p.allow) > 0 && !p.allow[channel] {
+		return fmt.Errorf("writing to channel %q is not allowed by SLACK_MCP_ADD_MESSAGE_TOOL", channel)
+	}
+
+	return nil
+}
+
+// Resolve resolves the target of a write tool and checks it against the
+// policy before anything is changed in Slack. A direct message that does
+// not exist yet is only opened when the policy allows writing to channels
+// that are not listed, as its ID cannot be on an allowlist.
+func (p *WritePolicy) Resolve(ctx context.Context, apiProvider *provider.ApiProvider, ref string) (string, error) {
+	if err := p.Enabled(); err != nil {
+		return "", err
+	}
+
+	channel, err := apiProvider.LookupChannel(ctx, ref)
+	if errors.Is(err, provider.ErrNoDirectMessage) {
+		if len(p.allow) > 0 {
+			return "", fmt.Errorf("writing to %q is not allowed by SLACK_MCP_ADD_MESSAGE_TOOL", ref)
+		}
+		channel, err = apiProvider.ResolveChannel(ctx, ref)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	if err := p.Check(channel); err != nil {
+		return "", err
+	}
+
+	return channel, nil
+}
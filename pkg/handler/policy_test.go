@@ -0,0 +1,85 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"testing"
+
+	"github.com/slack-go/slack"
+)
+
+func TestWritePolicyCheck(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  string
+		channel string
+		wantErr bool
+	}{
+		{name: "Unset disables writes", config: "", channel: "C1", wantErr: true},
+		{name: "False disables writes", config: "false", channel: "C1", wantErr: true},
+		{name: "True allows any channel", config: "true", channel: "C1", wantErr: false},
+		{name: "Allowlist permits listed channel", config: "C1, C2", channel: "C2", wantErr: false},
+		{name: "Allowlist rejects other channel", config: "C1,C2", channel: "C3", wantErr: true},
+		{name: "Denylist rejects listed channel", config: "!C1", channel: "C1", wantErr: true},
+		{name: "Denylist permits other channel", config: "!C1", channel: "C2", wantErr: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := NewWritePolicy(tt.config).Check(tt.channel)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Check(%q) error = %v, wantErr %v", tt.channel, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestWritePolicyResolve(t *testing.T) {
+	ctx := context.Background()
+
+	var opened atomic.Int64
+	users := []slack.User{{ID: "U0000000001", Name: "alice"}}
+	workspaces := newTestWorkspaces(t, users, map[string]http.HandlerFunc{
+		"conversations.list": writeJSON(map[string]any{"ok": true, "channels": []any{}}),
+		"conversations.open": func(w http.ResponseWriter, r *http.Request) {
+			opened.Add(1)
+			writeJSON(map[string]any{"ok": true, "channel": map[string]any{"id": "D0000000001"}})(w, r)
+		},
+	})
+	apiProvider, err := workspaces.Provider("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := apiProvider.Provide(); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		config     string
+		ref        string
+		want       string
+		wantErr    bool
+		wantOpened int64
+	}{
+		{name: "Disabled", config: "", ref: "@alice", wantErr: true},
+		{name: "Allowlist rejects new direct message", config: "C0000000001", ref: "@alice", wantErr: true},
+		{name: "Allowlist permits listed channel", config: "C0000000001", ref: "C0000000001", want: "C0000000001"},
+		{name: "Denylist rejects listed channel", config: "!C0000000001", ref: "C0000000001", wantErr: true},
+		{name: "Enabled opens direct message", config: "true", ref: "@alice", want: "D0000000001", wantOpened: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opened.Store(0)
+			got, err := NewWritePolicy(tt.config).Resolve(ctx, apiProvider, tt.ref)
+			if (err != nil) != tt.wantErr || got != tt.want {
+				t.Errorf("Resolve(%q) = %q, %v; want %q, wantErr %v", tt.ref, got, err, tt.want, tt.wantErr)
+			}
+			if n := opened.Load(); n != tt.wantOpened {
+				t.Errorf("conversations.open called %d times, want %d", n, tt.wantOpened)
+			}
+		})
+	}
+}
@@ -0,0 +1,165 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/korotovsky/slack-mcp-server/pkg/provider"
+	"github.com/korotovsky/slack-mcp-server/pkg/text"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/slack-go/slack"
+)
+
+// emojiRe matches an emoji name with an optional skin tone modifier, such
+// as "+1::skin-tone-2".
+var emojiRe = regexp.MustCompile(`^[^\s:]+(::skin-tone-[2-6])?$`)
+
+func (ch *ConversationsHandler) ReactionsAddHandler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return ch.react(ctx, request, true)
+}
+
+func (ch *ConversationsHandler) ReactionsRemoveHandler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return ch.react(ctx, request, false)
+}
+
+// react adds or removes a reaction and returns the message with its
+// reactions afterwards. Adding a reaction that is already there or
+// removing one that is not is not an error, so that agents can repeat
+// acknowledgements safely.
+func (ch *ConversationsHandler) react(ctx context.Context, request mcp.CallToolRequest, add bool) (*mcp.CallToolResult, error) {
+	channel := request.GetString("channel_id", "")
+	if channel == "" {
+		return nil, errors.New("channel_id must be a string")
+	}
+
+	timestamp := request.GetString("timestamp", "")
+	if !strings.Contains(timestamp, ".") {
+		return nil, errors.New("timestamp must be a valid timestamp in format 1234567890.123456")
+	}
+
+	emoji := strings.Trim(strings.TrimSpace(request.GetString("emoji", "")), ":")
+	if !emojiRe.MatchString(emoji) {
+		return nil, errors.New("emoji must be an emoji name such as 'eyes', 'white_check_mark' or '+1::skin-tone-2'")
+	}
+
+	format, err := requestFormat(request, ch.format)
+	if err != nil {
+		return nil, err
+	}
+
+	apiProvider, err := ch.workspaces.Provider(request.GetString("workspace", ""))
+	if err != nil {
+		return nil, err
+	}
+
+	api, err := apiProvider.Provide()
+	if err != nil {
+		return nil, err
+	}
+
+	channel, err = ch.writePolicy.Resolve(ctx, apiProvider, channel)
+	if err != nil {
+		return nil, err
+	}
+
+	ref := slack.NewRefToMessage(channel, timestamp)
+	if add {
+		err = api.AddReactionContext(ctx, emoji, ref)
+		if isSlackError(err, "already_reacted") {
+			err = nil
+		}
+	} else {
+		err = api.RemoveReactionContext(ctx, emoji, ref)
+		if isSlackError(err, "no_reaction") {
+			err = nil
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	messageList := readBackMessage(ctx, apiProvider, api, Message{Channel: channel, Time: timestamp}, ch.textOptions)
+
+	return messagesResult(messageList, format, ch.location)
+}
+
+// readBack returns the result of read, or stub if read fails. Write tools
+// read what they wrote back so that they answer in the same shape as the
+// read tools, but tokens with a write scope and without the matching read
+// scopes can write without being able to read, so stub holds what is
+// known from the write itself.
+func readBack[T any](what string, read func() (T, error), stub T) T {
+	result, err := read()
+	if err != nil {
+		log.Printf("Failed to read back %s: %v", what, err)
+		return stub
+	}
+
+	return result
+}
+
+// readBackMessage reads back the message identified by the channel and
+// time of stub, converted like history messages.
+func readBackMessage(ctx context.Context, apiProvider *provider.ApiProvider, api *slack.Client, stub Message, textOpts text.Options) []Message {
+	return readBack("message "+stub.Channel+"/"+stub.Time, func() ([]Message, error) {
+		message, err := readMessage(ctx, api, stub.Channel, stub.Time)
+		if err != nil {
+			return nil, err
+		}
+		return convertMessages(ctx, apiProvider, []slack.Message{message}, stub.Channel, textOpts), nil
+	}, []Message{stub})
+}
+
+// readMessage fetches a single message, which may be a thread reply.
+func readMessage(ctx context.Context, api *slack.Client, channel, ts string) (slack.Message, error) {
+	// conversations.replies accepts replies as well as parents, and always
+	// includes the parent, so bound the range to the message itself.
+	messages, _, _, err := api.GetConversationRepliesContext(ctx, &slack.GetConversationRepliesParameters{
+		ChannelID: channel,
+		Timestamp: ts,
+		Oldest:    ts,
+		Latest:    ts,
+		Inclusive: true,
+		Limit:     2,
+	})
+	if err != nil {
+		return slack.Message{}, err
+	}
+
+	for _, message := range messages {
+		if message.Timestamp == ts {
+			return message, nil
+		}
+	}
+
+	return slack.Message{}, fmt.Errorf("message %s not found in channel %s", ts, channel)
+}
+
+func isSlackError(err error, code string) bool {
+	var slackErr slack.SlackErrorResponse
+	return errors.As(err, &slackErr) && slackErr.Err == code
+}
+
+// formatReactions renders reactions compactly as "emoji:count:users" joined
+// by ";", with user names where known, e.g. "eyes:2:alice,bob".
+func formatReactions(reactions []slack.ItemReaction, usersMap map[string]slack.User) string {
+	parts := make([]string, 0, len(reactions))
+	for _, reaction := range reactions {
+		users := make([]string, 0, len(reaction.Users))
+		for _, id := range reaction.Users {
+			if user, ok := usersMap[id]; ok {
+				users = append(users, user.Name)
+			} else {
+				users = append(users, id)
+			}
+		}
+		parts = append(parts, reaction.Name+":"+strconv.Itoa(reaction.Count)+":"+strings.Join(users, ","))
+	}
+
+	return strings.Join(parts, ";")
+}
@@ -0,0 +1,90 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/korotovsky/slack-mcp-server/pkg/text"
+	"github.com/slack-go/slack"
+)
+
+func TestFormatReactions(t *testing.T) {
+	usersMap := map[string]slack.User{"U1": {ID: "U1", Name: "alice"}}
+	reactions := []slack.ItemReaction{
+		{Name: "eyes", Count: 2, Users: []string{"U1", "U2"}},
+		{Name: "white_check_mark", Count: 1, Users: []string{"U1"}},
+	}
+
+	want := "eyes:2:alice,U2;white_check_mark:1:alice"
+	if got := formatReactions(reactions, usersMap); got != want {
+		t.Errorf("formatReactions() = %q, want %q", got, want)
+	}
+	if got := formatReactions(nil, usersMap); got != "" {
+		t.Errorf("formatReactions(nil) = %q, want empty", got)
+	}
+}
+
+func TestEmojiRe(t *testing.T) {
+	for _, emoji := range []string{"eyes", "white_check_mark", "+1", "+1::skin-tone-2", "wave::skin-tone-6"} {
+		if !emojiRe.MatchString(emoji) {
+			t.Errorf("emojiRe rejects %q", emoji)
+		}
+	}
+	for _, emoji := range []string{"", "two words", "eyes:tada", "+1::skin-tone-7", "+1::skin-tone-"} {
+		if emojiRe.MatchString(emoji) {
+			t.Errorf("emojiRe accepts %q", emoji)
+		}
+	}
+}
+
+func TestReadMessage(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Like Slack, the parent comes first even when a reply is asked for.
+		json.NewEncoder(w).Encode(map[string]any{
+			"ok": true,
+			"messages": []map[string]any{
+				{"type": "message", "ts": "1700000000.000100", "thread_ts": "1700000000.000100", "text": "parent"},
+				{"type": "message", "ts": "1700000000.000200", "thread_ts": "1700000000.000100", "text": "reply",
+					"reactions": []map[string]any{{"name": "eyes", "count": 1, "users": []string{"U1"}}}},
+			},
+		})
+	}))
+	defer srv.Close()
+	api := slack.New("xoxb-test", slack.OptionAPIURL(srv.URL+"/"))
+
+	message, err := readMessage(context.Background(), api, "C0123456789", "1700000000.000200")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if message.Text != "reply" || len(message.Reactions) != 1 {
+		t.Errorf("readMessage() = %+v, want the reply with its reaction", message)
+	}
+
+	if _, err := readMessage(context.Background(), api, "C0123456789", "1700000000.000300"); err == nil {
+		t.Error("readMessage() of a missing message succeeded")
+	}
+}
+
+func TestReadBackMessageFallsBackToStub(t *testing.T) {
+	// Without a conversations.replies route the read fails, as it does for
+	// tokens without history scopes.
+	workspaces := newTestWorkspaces(t, nil, nil)
+	apiProvider, err := workspaces.Provider("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	api, err := apiProvider.Provide()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stub := Message{Channel: "C0123456789", Time: "1700000000.000100", Text: "posted"}
+	got := readBackMessage(context.Background(), apiProvider, api, stub, text.DefaultOptions)
+	if len(got) != 1 || !reflect.DeepEqual(got[0], stub) {
+		t.Errorf("readBackMessage() = %+v, want the stub", got)
+	}
+}
@@ -0,0 +1,91 @@
+package handler
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	slackTsRe  = regexp.MustCompile(`^\d{9,10}(\.\d{1,6})?$`)
+	relativeRe = regexp.MustCompile(`^(\d+)([smhdw])$`)
+)
+
+// localLayouts are the ISO-8601 forms without a zone offset accepted for
+// oldest and latest, interpreted in the configured timezone.
+var localLayouts = []string{
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+}
+
+// locationFromEnv returns the timezone configured by SLACK_MCP_TIMEZONE,
+// such as "Europe/Berlin", falling back to the server's local timezone.
+func locationFromEnv() *time.Location {
+	name := os.Getenv("SLACK_MCP_TIMEZONE")
+	if name == "" {
+		return time.Local
+	}
+
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		log.Printf("Ignoring SLACK_MCP_TIMEZONE: %v", err)
+		return time.Local
+	}
+
+	return loc
+}
+
+// parseTimestamp converts a point in time to a Slack timestamp. It accepts
+// Slack timestamps ("1700000000.123456"), RFC3339 timestamps, ISO-8601
+// dates and local date-times in the timezone of now, and durations before
+// now such as "30m", "2h", "3d" or "1w". A date alone stands for the start
+// of the day, or for the end of the day when endOfDay is set, so that a
+// range from "2026-09-01" to "2026-09-07" covers both days completely.
+func parseTimestamp(value string, now time.Time, endOfDay bool) (string, error) {
+	value = strings.TrimSpace(value)
+
+	if slackTsRe.MatchString(value) {
+		return value, nil
+	}
+
+	if m := relativeRe.FindStringSubmatch(value); m != nil {
+		n, _ := strconv.Atoi(m[1])
+		unit := map[string]time.Duration{
+			"s": time.Second,
+			"m": time.Minute,
+			"h": time.Hour,
+			"d": 24 * time.Hour,
+			"w": 7 * 24 * time.Hour,
+		}[m[2]]
+		return formatTs(now.Add(-time.Duration(n) * unit)), nil
+	}
+
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return formatTs(t), nil
+	}
+
+	if t, err := time.ParseInLocation("2006-01-02", value, now.Location()); err == nil {
+		if endOfDay {
+			t = t.AddDate(0, 0, 1)
+		}
+		return formatTs(t), nil
+	}
+
+	for _, layout := range localLayouts {
+		if t, err := time.ParseInLocation(layout, value, now.Location()); err == nil {
+			return formatTs(t), nil
+		}
+	}
+
+	return "", fmt.Errorf("invalid time %q: use a date (2026-09-01), an RFC3339 timestamp (2026-09-01T09:00:00Z), a Slack timestamp (1700000000.123456) or a duration before now (2h, 3d, 1w)", value)
+}
+
+func formatTs(t time.Time) string {
+	return fmt.Sprintf("%d.%06d", t.Unix(), t.Nanosecond()/1000)
+}
@@ -0,0 +1,81 @@
+package handler
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseTimestamp(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skip("timezone data not available")
+	}
+	now := time.Date(2026, 9, 10, 12, 0, 0, 0, berlin)
+
+	tests := []struct {
+		value    string
+		endOfDay bool
+		want     time.Time
+		wantTs   string
+		wantErr  bool
+	}{
+		{value: "1700000000.123456", wantTs: "1700000000.123456"},
+		{value: "1700000000", wantTs: "1700000000"},
+		{value: "2h", want: now.Add(-2 * time.Hour)},
+		{value: "1w", want: now.AddDate(0, 0, -7)},
+		{value: "2026-09-01T07:30:00Z", want: time.Date(2026, 9, 1, 7, 30, 0, 0, time.UTC)},
+		{value: "2026-09-01T09:30:00+02:00", want: time.Date(2026, 9, 1, 7, 30, 0, 0, time.UTC)},
+		{value: "2026-09-01", want: time.Date(2026, 9, 1, 0, 0, 0, 0, berlin)},
+		{value: "2026-09-07", endOfDay: true, want: time.Date(2026, 9, 8, 0, 0, 0, 0, berlin)},
+		{value: "2026-09-01 09:30", want: time.Date(2026, 9, 1, 9, 30, 0, 0, berlin)},
+		{value: "yesterday", wantErr: true},
+		{value: "2h30m", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := parseTimestamp(tt.value, now, tt.endOfDay)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseTimestamp() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			want := tt.wantTs
+			if !tt.want.IsZero() {
+				want = formatTs(tt.want)
+			}
+			if got != want {
+				t.Errorf("parseTimestamp() = %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestParseRange(t *testing.T) {
+	now := time.Date(2026, 9, 10, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		limit     string
+		oldest    string
+		latest    string
+		wantLimit int
+		wantErr   bool
+	}{
+		{name: "Default page size", oldest: "2026-09-01", latest: "2026-09-07", wantLimit: 100},
+		{name: "Numeric limit", limit: "20", oldest: "2h", wantLimit: 20},
+		{name: "Days limit", limit: "7d", oldest: "2h", wantErr: true},
+		{name: "Reversed range", oldest: "2026-09-07", latest: "2026-09-01", wantErr: true},
+		{name: "Invalid latest", latest: "soon", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limit, _, _, err := parseRange(tt.limit, tt.oldest, tt.latest, now)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseRange() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if limit != tt.wantLimit {
+				t.Errorf("parseRange() limit = %d, want %d", limit, tt.wantLimit)
+			}
+		})
+	}
+}
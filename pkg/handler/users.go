@@ -0,0 +1,185 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/korotovsky/slack-mcp-server/pkg/provider"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/slack-go/slack"
+)
+
+type User struct {
+	UserID      string `json:"userID"`
+	UserName    string `json:"userName"`
+	RealName    string `json:"realName"`
+	DisplayName string `json:"displayName"`
+	Email       string `json:"email"`
+	Title       string `json:"title"`
+	TimeZone    string `json:"timeZone"`
+	IsBot       bool   `json:"isBot"`
+	IsAdmin     bool   `json:"isAdmin"`
+	Deleted     bool   `json:"deleted"`
+	Cursor      string `json:"cursor"`
+}
+
+type UsersHandler struct {
+	workspaces *provider.Workspaces
+	format     Format
+}
+
+func NewUsersHandler(workspaces *provider.Workspaces) *UsersHandler {
+	return &UsersHandler{
+		workspaces: workspaces,
+		format:     formatFromEnv(),
+	}
+}
+
+func (uh *UsersHandler) UsersListHandler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	query := strings.ToLower(strings.TrimSpace(request.GetString("query", "")))
+	includeDeleted := request.GetBool("include_deleted", false)
+	includeBots := request.GetBool("include_bots", false)
+
+	limit := request.GetInt("limit", 100)
+	if limit <= 0 || limit > 1000 {
+		return nil, fmt.Errorf("invalid limit %d: must be between 1 and 1000", limit)
+	}
+
+	offset := 0
+	if cursor := request.GetString("cursor", ""); cursor != "" {
+		var err error
+		offset, err = strconv.Atoi(strings.TrimPrefix(cursor, "offset:"))
+		if err != nil || offset < 0 {
+			return nil, fmt.Errorf("invalid cursor %q", cursor)
+		}
+	}
+
+	format, err := requestFormat(request, uh.format)
+	if err != nil {
+		return nil, err
+	}
+
+	apiProvider, err := uh.workspaces.Provider(request.GetString("workspace", ""))
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := apiProvider.Provide(); err != nil {
+		return nil, err
+	}
+
+	var matched []slack.User
+	for _, user := range apiProvider.ProvideUsersMap() {
+		if user.Deleted && !includeDeleted {
+			continue
+		}
+		if (user.IsBot || user.ID == "USLACKBOT") && !includeBots {
+			continue
+		}
+		if query != "" && !userMatches(user, query) {
+			continue
+		}
+		matched = append(matched, user)
+	}
+
+	sort.Slice(matched, func(i, j int) bool {
+		return matched[i].Name < matched[j].Name
+	})
+
+	var userList []User
+	if offset < len(matched) {
+		end := offset + limit
+		if end > len(matched) {
+			end = len(matched)
+		}
+		for _, user := range matched[offset:end] {
+			userList = append(userList, toUser(user))
+		}
+		if end < len(matched) {
+			userList[len(userList)-1].Cursor = fmt.Sprintf("offset:%d", end)
+		}
+	}
+
+	return rowsResult(userList, format)
+}
+
+func (uh *UsersHandler) UserInfoHandler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	ids := request.GetString("user_id", "")
+	if ids == "" {
+		return nil, errors.New("user_id must be a string")
+	}
+
+	format, err := requestFormat(request, uh.format)
+	if err != nil {
+		return nil, err
+	}
+
+	apiProvider, err := uh.workspaces.Provider(request.GetString("workspace", ""))
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := apiProvider.Provide(); err != nil {
+		return nil, err
+	}
+
+	var userList []User
+	for _, id := range strings.Split(ids, ",") {
+		id = strings.TrimSpace(id)
+		if id == "" {
+			continue
+		}
+
+		if name, ok := strings.CutPrefix(id, "@"); ok {
+			user, err := apiProvider.UserByName(name)
+			if err != nil {
+				return nil, err
+			}
+			userList = append(userList, toUser(user))
+			continue
+		}
+
+		user, err := apiProvider.ProvideUser(ctx, id)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get user %q: %w", id, err)
+		}
+		userList = append(userList, toUser(user))
+	}
+
+	return rowsResult(userList, format)
+}
+
+func userMatches(user slack.User, query string) bool {
+	for _, field := range []string{
+		user.Name,
+		user.RealName,
+		user.Profile.DisplayName,
+		user.Profile.Email,
+		user.Profile.Title,
+	} {
+		if strings.Contains(strings.ToLower(field), query) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func toUser(user slack.User) User {
+	return User{
+		UserID:      user.ID,
+		UserName:    user.Name,
+		RealName:    user.RealName,
+		DisplayName: user.Profile.DisplayName,
+		Email:       user.Profile.Email,
+		Title:       user.Profile.Title,
+		TimeZone:    user.TZ,
+		IsBot:       user.IsBot,
+		IsAdmin:     user.IsAdmin,
+		Deleted:     user.Deleted,
+	}
+}
@@ -0,0 +1,140 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/slack-go/slack"
+)
+
+var testUsers = []slack.User{
+	{ID: "U0000000001", Name: "alice", RealName: "Alice Liddell", Profile: slack.UserProfile{Email: "alice@example.com", Title: "Platform Engineer"}},
+	{ID: "U0000000002", Name: "bob", RealName: "Bob Builder", Profile: slack.UserProfile{DisplayName: "bobby", Email: "bob@example.org"}},
+	{ID: "U0000000003", Name: "carol", RealName: "Carol Danvers", Profile: slack.UserProfile{Title: "Engineering Manager"}},
+	{ID: "U0000000004", Name: "dave", RealName: "Dave Former", Deleted: true},
+	{ID: "B0000000005", Name: "deploybot", RealName: "Deploy Bot", IsBot: true},
+	{ID: "USLACKBOT", Name: "slackbot", RealName: "Slackbot"},
+}
+
+// listUsers calls UsersListHandler and returns the decoded rows.
+func listUsers(t *testing.T, uh *UsersHandler, args map[string]any) []User {
+	t.Helper()
+
+	result, err := uh.UsersListHandler(context.Background(), callTool(args))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var users []User
+	if err := json.Unmarshal([]byte(resultText(t, result)), &users); err != nil {
+		t.Fatal(err)
+	}
+
+	return users
+}
+
+func userNames(users []User) []string {
+	names := make([]string, 0, len(users))
+	for _, user := range users {
+		names = append(names, user.UserName)
+	}
+	return names
+}
+
+func TestUsersListHandlerFilters(t *testing.T) {
+	t.Setenv("SLACK_MCP_OUTPUT_FORMAT", "json")
+	uh := NewUsersHandler(newTestWorkspaces(t, testUsers, nil))
+
+	tests := []struct {
+		name string
+		args map[string]any
+		want []string
+	}{
+		{"active people only", map[string]any{}, []string{"alice", "bob", "carol"}},
+		{"name", map[string]any{"query": "ALI"}, []string{"alice"}},
+		{"real name", map[string]any{"query": "builder"}, []string{"bob"}},
+		{"display name", map[string]any{"query": "bobby"}, []string{"bob"}},
+		{"email", map[string]any{"query": "example.org"}, []string{"bob"}},
+		{"title", map[string]any{"query": "engineer"}, []string{"alice", "carol"}},
+		{"deleted", map[string]any{"query": "dave", "include_deleted": true}, []string{"dave"}},
+		{"deleted excluded", map[string]any{"query": "dave"}, []string{}},
+		{"bots", map[string]any{"query": "bot", "include_bots": true}, []string{"deploybot", "slackbot"}},
+		{"bots excluded", map[string]any{"query": "bot"}, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := userNames(listUsers(t, uh, tt.args))
+			if len(got) != len(tt.want) {
+				t.Fatalf("UsersListHandler() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("UsersListHandler() = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestUsersListHandlerPagination(t *testing.T) {
+	t.Setenv("SLACK_MCP_OUTPUT_FORMAT", "json")
+	uh := NewUsersHandler(newTestWorkspaces(t, testUsers, nil))
+
+	first := listUsers(t, uh, map[string]any{"limit": 2})
+	if got := userNames(first); len(got) != 2 || got[0] != "alice" || got[1] != "bob" {
+		t.Fatalf("first page = %v, want [alice bob]", got)
+	}
+	cursor := first[len(first)-1].Cursor
+	if cursor != "offset:2" {
+		t.Fatalf("first page cursor = %q, want offset:2", cursor)
+	}
+
+	second := listUsers(t, uh, map[string]any{"limit": 2, "cursor": cursor})
+	if got := userNames(second); len(got) != 1 || got[0] != "carol" {
+		t.Fatalf("second page = %v, want [carol]", got)
+	}
+	if second[0].Cursor != "" {
+		t.Errorf("last page cursor = %q, want empty", second[0].Cursor)
+	}
+
+	if _, err := uh.UsersListHandler(context.Background(), callTool(map[string]any{"cursor": "offset:x"})); err == nil {
+		t.Error("UsersListHandler() with an invalid cursor succeeded")
+	}
+}
+
+func TestUserInfoHandler(t *testing.T) {
+	t.Setenv("SLACK_MCP_OUTPUT_FORMAT", "json")
+
+	var lookups []string
+	uh := NewUsersHandler(newTestWorkspaces(t, testUsers, map[string]http.HandlerFunc{
+		"users.info": func(w http.ResponseWriter, r *http.Request) {
+			lookups = append(lookups, r.Form.Get("user"))
+			writeJSON(map[string]any{"ok": true, "user": map[string]any{"id": r.Form.Get("user"), "name": "guest"}})(w, r)
+		},
+	}))
+
+	info := func(ids string) []User {
+		t.Helper()
+		result, err := uh.UserInfoHandler(context.Background(), callTool(map[string]any{"user_id": ids}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var users []User
+		if err := json.Unmarshal([]byte(resultText(t, result)), &users); err != nil {
+			t.Fatal(err)
+		}
+		return users
+	}
+
+	users := info("U0000000001, @bob, W0000000009")
+	if got := userNames(users); len(got) != 3 || got[0] != "alice" || got[1] != "bob" || got[2] != "guest" {
+		t.Errorf("UserInfoHandler() = %v, want [alice bob guest]", got)
+	}
+
+	// The user fetched on the miss is served from the cache afterwards.
+	info("W0000000009")
+	if len(lookups) != 1 || lookups[0] != "W0000000009" {
+		t.Errorf("users.info called for %v, want [W0000000009]", lookups)
+	}
+}
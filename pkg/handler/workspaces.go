@@ -0,0 +1,51 @@
+package handler
+
+import (
+	"context"
+
+	"github.com/korotovsky/slack-mcp-server/pkg/provider"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+type Workspace struct {
+	Name     string `json:"name"`
+	Default  bool   `json:"default"`
+	Team     string `json:"team"`
+	User     string `json:"user"`
+	AuthMode string `json:"authMode"`
+	Status   string `json:"status"`
+}
+
+type WorkspacesHandler struct {
+	workspaces *provider.Workspaces
+	format     Format
+}
+
+func NewWorkspacesHandler(workspaces *provider.Workspaces) *WorkspacesHandler {
+	return &WorkspacesHandler{
+		workspaces: workspaces,
+		format:     formatFromEnv(),
+	}
+}
+
+func (wh *WorkspacesHandler) WorkspacesListHandler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	format, err := requestFormat(request, wh.format)
+	if err != nil {
+		return nil, err
+	}
+
+	var workspaceList []Workspace
+	for _, apiProvider := range wh.workspaces.All() {
+		h := apiProvider.Health()
+		workspaceList = append(workspaceList, Workspace{
+			Name:     apiProvider.Name(),
+			Default:  apiProvider.Name() == wh.workspaces.Default(),
+			Team:     h.Team,
+			User:     h.User,
+			AuthMode: h.AuthMode,
+			Status:   h.Status,
+		})
+	}
+
+	return rowsResult(workspaceList, format)
+}
@@ -4,35 +4,76 @@ import (
 	"context"
 	"crypto/tls"
 	"crypto/x509"
-	"encoding/json"
-	"io/ioutil"
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
 	"net/url"
 	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/korotovsky/slack-mcp-server/pkg/transport"
 	"github.com/slack-go/slack"
 )
 
+// ApiProvider owns the Slack client and the caches shared by all tool
+// handlers. It is safe for concurrent use: concurrent callers of Provide
+// wait for a single boot, and the users map is replaced copy-on-write so
+// that snapshots returned by ProvideUsersMap can be read without locking.
+// Users and channels fetched one at a time on a cache miss are kept in a
+// pending set instead, and folded into the next snapshot in one copy.
 type ApiProvider struct {
-	boot   func() *slack.Client
-	client *slack.Client
+	name     string
+	boot     func() (*slack.Client, error)
+	bootMu   sync.Mutex
+	client   atomic.Pointer[slack.Client]
+	authMode AuthMode
 
-	users      map[string]slack.User
-	usersCache string
+	health   Health
+	healthMu sync.RWMutex
+
+	users           map[string]slack.User
+	usersPending    map[string]slack.User
+	usersMu         sync.RWMutex
+	usersRefreshMu  sync.Mutex
+	usersRefreshing atomic.Bool
+	usersCache      string
+	usersCacheTTL   time.Duration
+	usersRefreshInt time.Duration
+
+	channels           *channelDirectory
+	channelsPending    map[string]slack.Channel
+	channelsMu         sync.RWMutex
+	channelsRefreshMu  sync.Mutex
+	channelsCache      string
+	channelsCacheTTL   time.Duration
+	channelsRefreshInt time.Duration
+
+	retry atomic.Pointer[transport.RetryTransport]
 }
 
-func New() *ApiProvider {
-	token := os.Getenv("SLACK_MCP_XOXC_TOKEN")
-	if token == "" {
-		panic("SLACK_MCP_XOXC_TOKEN environment variable is required")
-	}
+// config describes a single workspace connection.
+type config struct {
+	name                 string
+	creds                credentials
+	usersCache           string
+	usersCacheTTL        time.Duration
+	usersRefreshInterval time.Duration
 
-	cookie := os.Getenv("SLACK_MCP_XOXD_TOKEN")
-	if cookie == "" {
-		panic("SLACK_MCP_XOXD_TOKEN environment variable is required")
-	}
+	channelsCache           string
+	channelsCacheTTL        time.Duration
+	channelsRefreshInterval time.Duration
+}
+
+// New creates a provider from the environment. Missing or invalid
+// configuration does not stop the server: it is reported by every tool
+// call and by Health until the process is restarted with a fixed setup.
+func New() *ApiProvider {
+	creds, configErr := credentialsFromEnv()
 
 	userCachePath := ""
 	enableUserCache := os.Getenv("SLACK_MCP_ENABLE_USER_CACHE")
@@ -41,167 +82,319 @@ func New() *ApiProvider {
 		if userCachePath == "" {
 			userCachePath = ".users_cache.json"
 		}
-		log.Printf("User caching to disk is ENABLED. Cache path: %s", userCachePath)
-	} else {
-		log.Printf("User caching to disk is DISABLED.")
 	}
 
-	return &ApiProvider{
-		boot: func() *slack.Client {
-			api := slack.New(token,
-				withHTTPClientOption(cookie),
-			)
-			res, err := api.AuthTest()
-			if err != nil {
-				panic(err)
-			} else {
-				log.Printf("Authenticated as: %s\n", res)
-			}
-
-			api = slack.New(token,
-				withHTTPClientOption(cookie),
-				withTeamEndpointOption(res.URL),
-			)
-
-			return api
-		},
-		users:      make(map[string]slack.User),
-		usersCache: userCachePath, // This will be empty if caching is disabled
+	channelsCachePath := ""
+	if os.Getenv("SLACK_MCP_ENABLE_CHANNEL_CACHE") == "true" {
+		channelsCachePath = os.Getenv("SLACK_MCP_CHANNELS_CACHE")
+		if channelsCachePath == "" {
+			channelsCachePath = ".channels_cache.json"
+		}
 	}
+
+	return newProvider(config{
+		name:                    DefaultWorkspace,
+		creds:                   creds,
+		usersCache:              userCachePath,
+		usersCacheTTL:           durationFromEnv("SLACK_MCP_USERS_CACHE_TTL", defaultUsersCacheTTL),
+		usersRefreshInterval:    durationFromEnv("SLACK_MCP_USERS_REFRESH_INTERVAL", defaultUsersRefreshInterval),
+		channelsCache:           channelsCachePath,
+		channelsCacheTTL:        durationFromEnv("SLACK_MCP_CHANNELS_CACHE_TTL", defaultChannelsCacheTTL),
+		channelsRefreshInterval: durationFromEnv("SLACK_MCP_CHANNELS_REFRESH_INTERVAL", defaultChannelsRefreshInterval),
+	}, configErr)
 }
 
-func (ap *ApiProvider) Provide() (*slack.Client, error) {
-	if ap.client == nil {
-		ap.client = ap.boot()
+func newProvider(cfg config, configErr error) *ApiProvider {
+	creds := cfg.creds
+	log.Printf("[%s] Using %s auth mode.", cfg.name, creds.mode)
+	if cfg.usersCache != "" {
+		log.Printf("[%s] User caching to disk is ENABLED. Cache path: %s", cfg.name, cfg.usersCache)
+	} else {
+		log.Printf("[%s] User caching to disk is DISABLED.", cfg.name)
+	}
+	if cfg.channelsCache != "" {
+		log.Printf("[%s] Channel caching to disk is ENABLED. Cache path: %s", cfg.name, cfg.channelsCache)
+	}
+
+	ap := &ApiProvider{
+		name:               cfg.name,
+		users:              make(map[string]slack.User),
+		usersCache:         cfg.usersCache, // This will be empty if caching is disabled
+		usersCacheTTL:      cfg.usersCacheTTL,
+		usersRefreshInt:    cfg.usersRefreshInterval,
+		channelsCache:      cfg.channelsCache,
+		channelsCacheTTL:   cfg.channelsCacheTTL,
+		channelsRefreshInt: cfg.channelsRefreshInterval,
+		authMode:           creds.mode,
+		health:             Health{Status: HealthStarting, AuthMode: string(creds.mode)},
+	}
 
-		err := ap.bootstrapDependencies(context.Background())
+	ap.boot = func() (*slack.Client, error) {
+		if configErr != nil {
+			return nil, configErr
+		}
+
+		httpClient, err := newHTTPClient(creds)
 		if err != nil {
 			return nil, err
 		}
+
+		retry := newRetryTransport(httpClient.Transport)
+		httpClient.Transport = retry
+		ap.retry.Store(retry)
+
+		api := slack.New(creds.token,
+			slack.OptionHTTPClient(httpClient),
+		)
+		res, err := api.AuthTest()
+		if err != nil {
+			return nil, describeAuthError(creds.mode, err)
+		}
+		log.Printf("[%s] Authenticated as: %s\n", cfg.name, res)
+
+		ap.healthMu.Lock()
+		ap.health.Team = res.Team
+		ap.health.User = res.User
+		ap.healthMu.Unlock()
+
+		// Browser sessions only work against the workspace's own domain,
+		// app tokens use the default slack.com endpoint.
+		if creds.mode == AuthModeXoxc {
+			api = slack.New(creds.token,
+				slack.OptionHTTPClient(httpClient),
+				withTeamEndpointOption(res.URL),
+			)
+		}
+
+		return api, nil
+	}
+
+	if configErr != nil {
+		log.Printf("[%s] Provider is not configured: %v", cfg.name, configErr)
+		ap.health.Status = HealthFailed
+		ap.health.Error = configErr.Error()
 	}
 
-	return ap.client, nil
+	return ap
 }
 
-func (ap *ApiProvider) bootstrapDependencies(ctx context.Context) error {
-	// Attempt to load from cache only if caching is enabled (usersCache is not empty)
-	if ap.usersCache != "" {
-		if data, err := ioutil.ReadFile(ap.usersCache); err == nil {
-			var cachedUsers []slack.User
-			if err := json.Unmarshal(data, &cachedUsers); err != nil {
-				log.Printf("Failed to unmarshal %s: %v; will refetch", ap.usersCache, err)
-			} else {
-				for _, u := range cachedUsers {
-					ap.users[u.ID] = u
-				}
-				log.Printf("Loaded %d users from cache %q", len(cachedUsers), ap.usersCache)
-				return nil
-			}
-		} else {
-			// Log if file doesn't exist or other read error, but proceed to fetch if cache was enabled
-			if !os.IsNotExist(err) {
-				log.Printf("Failed to read cache file %s: %v; will refetch", ap.usersCache, err)
-			}
-		}
+// NewWithClient creates a provider around an existing client, for example
+// one pointed at a different API URL. Users are loaded on first use.
+func NewWithClient(name string, client *slack.Client) *ApiProvider {
+	return &ApiProvider{
+		name:   name,
+		boot:   func() (*slack.Client, error) { return client, nil },
+		users:  make(map[string]slack.User),
+		health: Health{Status: HealthStarting},
 	}
+}
 
-	log.Printf("Fetching users from API...")
-	optionLimit := slack.GetUsersOptionLimit(1000)
+// Name returns the workspace name the provider is registered under.
+func (ap *ApiProvider) Name() string {
+	return ap.name
+}
+
+// Provide returns the Slack client, booting it and loading the users on
+// first use. Concurrent callers block until the boot has finished; a failed
+// boot is retried by the next call.
+func (ap *ApiProvider) Provide() (*slack.Client, error) {
+	if client := ap.client.Load(); client != nil {
+		return client, nil
+	}
+
+	ap.bootMu.Lock()
+	defer ap.bootMu.Unlock()
+
+	if client := ap.client.Load(); client != nil {
+		return client, nil
+	}
 
-	users, err := ap.client.GetUsersContext(ctx,
-		optionLimit,
-	)
+	client, err := ap.boot()
+	if err == nil {
+		err = ap.bootstrapDependencies(context.Background(), client)
+	}
 	if err != nil {
-		log.Printf("Failed to fetch users: %v", err)
-		return err
+		ap.setHealth(HealthFailed, err)
+		return nil, fmt.Errorf("slack provider is not ready: %w", err)
 	}
 
-	for _, user := range users {
-		ap.users[user.ID] = user
+	ap.client.Store(client)
+	ap.setHealth(HealthReady, nil)
+
+	return client, nil
+}
+
+func (ap *ApiProvider) bootstrapDependencies(ctx context.Context, client *slack.Client) error {
+	if ap.loadUsersCache() {
+		return nil
 	}
 
-	// Attempt to write to cache only if caching is enabled (usersCache is not empty)
-	if ap.usersCache != "" {
-		if data, err := json.MarshalIndent(users, "", "  "); err != nil {
-			log.Printf("Failed to marshal users for cache: %v", err)
-		} else {
-			if err := ioutil.WriteFile(ap.usersCache, data, 0644); err != nil {
-				log.Printf("Failed to write cache file %q: %v", ap.usersCache, err)
-			} else {
-				log.Printf("Wrote %d users to cache %q", len(users), ap.usersCache)
-			}
-		}
+	err := ap.refreshUsers(ctx, client)
+	if isScopeError(err) {
+		// Bot and app tokens may lack users:read, tools still work and
+		// show user IDs instead of names.
+		log.Printf("Users are not available with the %s token, continuing without them: %v", ap.authMode, err)
+		return nil
 	}
 
-	return nil
+	return err
 }
 
+// ProvideUsersMap returns a snapshot of the users map, including the users
+// fetched on demand since the last snapshot. The snapshot is never
+// modified after it is published and must not be modified by the caller.
 func (ap *ApiProvider) ProvideUsersMap() map[string]slack.User {
+	ap.usersMu.RLock()
+	users, pending := ap.users, len(ap.usersPending)
+	ap.usersMu.RUnlock()
+
+	if pending == 0 {
+		return users
+	}
+
+	ap.usersMu.Lock()
+	defer ap.usersMu.Unlock()
+
+	ap.mergeUsersLocked(nil, false)
+
 	return ap.users
 }
 
-func withHTTPClientOption(cookie string) func(c *slack.Client) {
-	return func(c *slack.Client) {
-		var proxy func(*http.Request) (*url.URL, error)
-		if proxyURL := os.Getenv("SLACK_MCP_PROXY"); proxyURL != "" {
-			parsed, err := url.Parse(proxyURL)
-			if err != nil {
-				log.Fatalf("Failed to parse proxy URL: %v", err)
-			}
-
-			proxy = http.ProxyURL(parsed)
-		} else {
-			proxy = nil
-		}
+// ProvideUser returns the user with the given ID from the users map. Users
+// that are not known yet, such as new hires or Slack Connect guests, are
+// fetched from the API and merged into the map.
+func (ap *ApiProvider) ProvideUser(ctx context.Context, id string) (slack.User, error) {
+	if user, ok := ap.lookupUser(id); ok {
+		return user, nil
+	}
 
-		rootCAs, _ := x509.SystemCertPool()
-		if rootCAs == nil {
-			rootCAs = x509.NewCertPool()
-		}
+	client, err := ap.Provide()
+	if err != nil {
+		return slack.User{}, err
+	}
 
-		if localCertFile := os.Getenv("SLACK_MCP_SERVER_CA"); localCertFile != "" {
-			certs, err := ioutil.ReadFile(localCertFile)
-			if err != nil {
-				log.Fatalf("Failed to append %q to RootCAs: %v", localCertFile, err)
-			}
-
-			if ok := rootCAs.AppendCertsFromPEM(certs); !ok {
-				log.Println("No certs appended, using system certs only")
-			}
+	user, err := client.GetUserInfoContext(ctx, id)
+	if err != nil {
+		return slack.User{}, err
+	}
+
+	ap.addUser(*user)
+
+	return *user, nil
+}
+
+// ProvideChannel returns the channel with the given ID from the channel
+// directory, fetching it from the API on a miss.
+func (ap *ApiProvider) ProvideChannel(ctx context.Context, id string) (slack.Channel, error) {
+	if channel, ok := ap.lookupChannel(id); ok {
+		return channel, nil
+	}
+
+	client, err := ap.Provide()
+	if err != nil {
+		return slack.Channel{}, err
+	}
+
+	info, err := client.GetConversationInfoContext(ctx, &slack.GetConversationInfoInput{
+		ChannelID: id,
+	})
+	if err != nil {
+		return slack.Channel{}, err
+	}
+
+	ap.addChannel(*info)
+
+	return *info, nil
+}
+
+func newHTTPClient(creds credentials) (*http.Client, error) {
+	var proxy func(*http.Request) (*url.URL, error)
+	if proxyURL := os.Getenv("SLACK_MCP_PROXY"); proxyURL != "" {
+		parsed, err := url.Parse(proxyURL)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse proxy URL: %w", err)
 		}
 
-		insecure := false
-		if os.Getenv("SLACK_MCP_SERVER_CA_INSECURE") != "" {
-			if localCertFile := os.Getenv("SLACK_MCP_SERVER_CA"); localCertFile != "" {
-				log.Fatalf("Variable SLACK_MCP_SERVER_CA is at the same time with SLACK_MCP_SERVER_CA_INSECURE")
-			}
-			insecure = true
+		proxy = http.ProxyURL(parsed)
+	} else {
+		proxy = nil
+	}
+
+	rootCAs, _ := x509.SystemCertPool()
+	if rootCAs == nil {
+		rootCAs = x509.NewCertPool()
+	}
+
+	if localCertFile := os.Getenv("SLACK_MCP_SERVER_CA"); localCertFile != "" {
+		certs, err := os.ReadFile(localCertFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to append %q to RootCAs: %w", localCertFile, err)
 		}
 
-		customHTTPTransport := &http.Transport{
-			Proxy: proxy,
-			TLSClientConfig: &tls.Config{
-				InsecureSkipVerify: insecure,
-				RootCAs:            rootCAs,
-			},
+		if ok := rootCAs.AppendCertsFromPEM(certs); !ok {
+			log.Println("No certs appended, using system certs only")
 		}
+	}
 
-		dsCookie := os.Getenv("SLACK_MCP_DS_COOKIE")
-		if dsCookie == "" {
-			dsCookie = "1744415074" // Default value
+	insecure := false
+	if os.Getenv("SLACK_MCP_SERVER_CA_INSECURE") != "" {
+		if localCertFile := os.Getenv("SLACK_MCP_SERVER_CA"); localCertFile != "" {
+			return nil, errors.New("variable SLACK_MCP_SERVER_CA is set at the same time as SLACK_MCP_SERVER_CA_INSECURE")
 		}
+		insecure = true
+	}
 
-		client := &http.Client{
-			Transport: transport.New(
-				customHTTPTransport,
-				"Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/133.0.0.0 Safari/537.36",
-				cookie,
-				dsCookie,
-			),
+	customHTTPTransport := &http.Transport{
+		Proxy: proxy,
+		TLSClientConfig: &tls.Config{
+			InsecureSkipVerify: insecure,
+			RootCAs:            rootCAs,
+		},
+	}
+
+	// Official app tokens must not carry the browser cookie or the spoofed
+	// user agent, only browser sessions need them.
+	if creds.mode != AuthModeXoxc {
+		return &http.Client{Transport: customHTTPTransport}, nil
+	}
+
+	dsCookie := os.Getenv("SLACK_MCP_DS_COOKIE")
+	if dsCookie == "" {
+		dsCookie = "1744415074" // Default value
+	}
+
+	client := &http.Client{
+		Transport: transport.New(
+			customHTTPTransport,
+			"Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/133.0.0.0 Safari/537.36",
+			creds.cookie,
+			dsCookie,
+		),
+	}
+
+	return client, nil
+}
+
+// newRetryTransport wraps rt with retries configured by SLACK_MCP_MAX_RETRIES
+// and SLACK_MCP_TIER_BUDGETS.
+func newRetryTransport(rt http.RoundTripper) *transport.RetryTransport {
+	maxRetries := -1
+	if v := os.Getenv("SLACK_MCP_MAX_RETRIES"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			log.Printf("Invalid SLACK_MCP_MAX_RETRIES %q, using the default", v)
+		} else {
+			maxRetries = n
 		}
+	}
 
-		slack.OptionHTTPClient(client)(c)
+	tierBudgets := true
+	switch strings.ToLower(os.Getenv("SLACK_MCP_TIER_BUDGETS")) {
+	case "false", "0", "no", "off":
+		tierBudgets = false
 	}
+
+	return transport.NewRetry(rt, maxRetries, tierBudgets)
 }
 
 func withTeamEndpointOption(url string) slack.Option {
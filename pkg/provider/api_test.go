@@ -0,0 +1,283 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/slack-go/slack"
+)
+
+// fakeSlack serves the subset of the Slack Web API used by ApiProvider.
+type fakeSlack struct {
+	users    []slack.User
+	channels []slack.Channel
+	pageSize int
+	calls    sync.Map
+
+	// noScope lists conversation types that fail with missing_scope.
+	noScope map[string]bool
+}
+
+func (f *fakeSlack) count(method string) int64 {
+	v, _ := f.calls.LoadOrStore(method, new(atomic.Int64))
+	return v.(*atomic.Int64).Load()
+}
+
+func (f *fakeSlack) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	method := r.URL.Path[1:]
+	v, _ := f.calls.LoadOrStore(method, new(atomic.Int64))
+	v.(*atomic.Int64).Add(1)
+
+	_ = r.ParseForm()
+	w.Header().Set("Content-Type", "application/json")
+
+	switch method {
+	case "users.list":
+		offset := 0
+		fmt.Sscanf(r.Form.Get("cursor"), "%d", &offset)
+		end := offset + f.pageSize
+		next := fmt.Sprint(end)
+		if end >= len(f.users) {
+			end = len(f.users)
+			next = ""
+		}
+		json.NewEncoder(w).Encode(map[string]any{
+			"ok":                true,
+			"members":           f.users[offset:end],
+			"response_metadata": map[string]string{"next_cursor": next},
+		})
+	case "users.info":
+		id := r.Form.Get("user")
+		json.NewEncoder(w).Encode(map[string]any{
+			"ok":   true,
+			"user": slack.User{ID: id, Name: "guest-" + id},
+		})
+	case "conversations.info":
+		id := r.Form.Get("channel")
+		json.NewEncoder(w).Encode(map[string]any{
+			"ok":      true,
+			"channel": map[string]any{"id": id, "name": "channel-" + id},
+		})
+	case "conversations.open":
+		json.NewEncoder(w).Encode(map[string]any{
+			"ok":      true,
+			"channel": map[string]any{"id": "D" + r.Form.Get("users")},
+		})
+	case "conversations.list":
+		channelType := r.Form.Get("types")
+		if f.noScope[channelType] {
+			json.NewEncoder(w).Encode(map[string]any{"ok": false, "error": "missing_scope"})
+			return
+		}
+		var channels []slack.Channel
+		for _, channel := range f.channels {
+			if ChannelType(channel) == channelType {
+				channels = append(channels, channel)
+			}
+		}
+		json.NewEncoder(w).Encode(map[string]any{
+			"ok":                true,
+			"channels":          channels,
+			"response_metadata": map[string]string{"next_cursor": ""},
+		})
+	default:
+		json.NewEncoder(w).Encode(map[string]any{"ok": false, "error": "unknown_method"})
+	}
+}
+
+func newTestProvider(t *testing.T, users int) (*ApiProvider, *fakeSlack, *atomic.Int64) {
+	t.Helper()
+
+	fake := &fakeSlack{pageSize: 3}
+	for i := 0; i < users; i++ {
+		fake.users = append(fake.users, slack.User{ID: fmt.Sprintf("U%03d", i), Name: fmt.Sprintf("user%d", i)})
+	}
+
+	srv := httptest.NewServer(fake)
+	t.Cleanup(srv.Close)
+
+	boots := new(atomic.Int64)
+	ap := &ApiProvider{
+		boot: func() (*slack.Client, error) {
+			boots.Add(1)
+			return slack.New("xoxc-test", slack.OptionAPIURL(srv.URL+"/")), nil
+		},
+		users: make(map[string]slack.User),
+	}
+
+	return ap, fake, boots
+}
+
+func TestProvideBootsOnce(t *testing.T) {
+	ap, fake, boots := newTestProvider(t, 10)
+
+	const callers = 50
+	clients := make([]*slack.Client, callers)
+
+	var wg sync.WaitGroup
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			client, err := ap.Provide()
+			if err != nil {
+				t.Errorf("Provide() error = %v", err)
+			}
+			clients[i] = client
+		}(i)
+	}
+	wg.Wait()
+
+	if n := boots.Load(); n != 1 {
+		t.Errorf("boot called %d times, want 1", n)
+	}
+	for i, client := range clients {
+		if client != clients[0] {
+			t.Errorf("caller %d got a different client", i)
+		}
+	}
+	if n := len(ap.ProvideUsersMap()); n != 10 {
+		t.Errorf("loaded %d users, want 10", n)
+	}
+	if n := fake.count("users.list"); n != 4 {
+		t.Errorf("users.list called %d times, want 4 pages", n)
+	}
+}
+
+func TestConcurrentCacheAccess(t *testing.T) {
+	ap, _, _ := newTestProvider(t, 20)
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(4)
+
+		go func(i int) {
+			defer wg.Done()
+			id := fmt.Sprintf("W%03d", i)
+			user, err := ap.ProvideUser(ctx, id)
+			if err != nil {
+				t.Errorf("ProvideUser(%s) error = %v", id, err)
+				return
+			}
+			if user.ID != id {
+				t.Errorf("ProvideUser(%s) returned %s", id, user.ID)
+			}
+		}(i)
+
+		go func() {
+			defer wg.Done()
+			for id, user := range ap.ProvideUsersMap() {
+				if id != user.ID {
+					t.Errorf("users map key %s holds user %s", id, user.ID)
+				}
+			}
+		}()
+
+		go func() {
+			defer wg.Done()
+			if err := ap.RefreshUsers(ctx); err != nil {
+				t.Errorf("RefreshUsers() error = %v", err)
+			}
+		}()
+
+		go func(i int) {
+			defer wg.Done()
+			id := fmt.Sprintf("C%03d", i%5)
+			if _, err := ap.ProvideChannel(ctx, id); err != nil {
+				t.Errorf("ProvideChannel(%s) error = %v", id, err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	users := ap.ProvideUsersMap()
+	for i := 0; i < 20; i++ {
+		if _, ok := users[fmt.Sprintf("U%03d", i)]; !ok {
+			t.Errorf("workspace user U%03d missing after refresh", i)
+		}
+		if _, ok := users[fmt.Sprintf("W%03d", i)]; !ok {
+			t.Errorf("on-demand user W%03d lost after refresh", i)
+		}
+	}
+}
+
+func TestOnDemandInsertsAreBatched(t *testing.T) {
+	ap, fake, _ := newTestProvider(t, 5)
+	ctx := context.Background()
+	if _, err := ap.Provide(); err != nil {
+		t.Fatal(err)
+	}
+	snapshot := ap.ProvideUsersMap()
+
+	for i := 0; i < 3; i++ {
+		id := fmt.Sprintf("W%03d", i)
+		if _, err := ap.ProvideUser(ctx, id); err != nil {
+			t.Fatal(err)
+		}
+		// A second call is served from the pending users.
+		if _, err := ap.ProvideUser(ctx, id); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := fake.count("users.info"); got != 3 {
+		t.Errorf("users.info called %d times, want 3", got)
+	}
+
+	ap.usersMu.RLock()
+	published := len(ap.users)
+	ap.usersMu.RUnlock()
+	if published != len(snapshot) {
+		t.Errorf("users map was republished on each insert: %d users, want %d", published, len(snapshot))
+	}
+
+	users := ap.ProvideUsersMap()
+	if len(users) != len(snapshot)+3 {
+		t.Errorf("ProvideUsersMap() has %d users, want %d", len(users), len(snapshot)+3)
+	}
+	if len(snapshot) != 5 {
+		t.Errorf("earlier snapshot was modified: %d users, want 5", len(snapshot))
+	}
+}
+
+func TestProvideRetriesFailedBoot(t *testing.T) {
+	ap, _, boots := newTestProvider(t, 1)
+	client := ap.boot
+
+	ap.boot = func() (*slack.Client, error) {
+		boots.Add(1)
+		return nil, errors.New("invalid_auth")
+	}
+	if _, err := ap.Provide(); err == nil {
+		t.Fatal("Provide() expected error")
+	}
+	if h := ap.Health(); h.Status != HealthFailed || h.Error == "" {
+		t.Errorf("Health() = %+v, want failed with error", h)
+	}
+
+	ap.boot = client
+	if _, err := ap.Provide(); err != nil {
+		t.Fatalf("Provide() error = %v", err)
+	}
+	if h := ap.Health(); h.Status != HealthReady || h.Error != "" || h.Users != 1 {
+		t.Errorf("Health() = %+v, want ready with 1 user", h)
+	}
+	if n := boots.Load(); n != 2 {
+		t.Errorf("boot called %d times, want 2", n)
+	}
+}
+
+func TestDescribeAuthError(t *testing.T) {
+	err := describeAuthError(AuthModeXoxc, slack.SlackErrorResponse{Err: "invalid_auth"})
+	var slackErr slack.SlackErrorResponse
+	if !errors.As(err, &slackErr) || slackErr.Err != "invalid_auth" {
+		t.Errorf("describeAuthError() lost the original error: %v", err)
+	}
+}
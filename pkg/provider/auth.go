@@ -0,0 +1,128 @@
+package provider
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/slack-go/slack"
+)
+
+// AuthMode selects which kind of Slack credentials the provider uses.
+type AuthMode string
+
+const (
+	// AuthModeXoxc uses a browser session token together with its "d"
+	// cookie and mimics the Slack web client.
+	AuthModeXoxc AuthMode = "xoxc"
+	// AuthModeXoxp uses an OAuth user token of an installed Slack app.
+	AuthModeXoxp AuthMode = "xoxp"
+	// AuthModeXoxb uses a bot token of an installed Slack app.
+	AuthModeXoxb AuthMode = "xoxb"
+)
+
+type credentials struct {
+	mode   AuthMode
+	token  string
+	cookie string
+}
+
+// credentialsFromEnv selects the auth mode from SLACK_MCP_AUTH_MODE or,
+// when it is not set, from the first token variable that is present in
+// the order xoxp, xoxb, xoxc.
+func credentialsFromEnv() (credentials, error) {
+	return credentialsFrom(os.Getenv, func(key string) string {
+		return key + " environment variable"
+	})
+}
+
+// credentialsFrom resolves credentials from settings named after the
+// SLACK_MCP_* environment variables. describe names a setting in error
+// messages, so that other configuration sources can report their own keys.
+func credentialsFrom(lookup func(key string) string, describe func(key string) string) (credentials, error) {
+	mode := AuthMode(strings.ToLower(strings.TrimSpace(lookup("SLACK_MCP_AUTH_MODE"))))
+	if mode == "" {
+		switch {
+		case lookup("SLACK_MCP_XOXP_TOKEN") != "":
+			mode = AuthModeXoxp
+		case lookup("SLACK_MCP_XOXB_TOKEN") != "":
+			mode = AuthModeXoxb
+		default:
+			mode = AuthModeXoxc
+		}
+	}
+
+	required := func(key string) (string, error) {
+		value := lookup(key)
+		if value == "" {
+			return "", fmt.Errorf("%s is required", describe(key))
+		}
+		return value, nil
+	}
+
+	var err error
+	creds := credentials{mode: mode}
+	switch mode {
+	case AuthModeXoxc:
+		if creds.token, err = required("SLACK_MCP_XOXC_TOKEN"); err != nil {
+			return creds, err
+		}
+		if creds.cookie, err = required("SLACK_MCP_XOXD_TOKEN"); err != nil {
+			return creds, err
+		}
+	case AuthModeXoxp:
+		if creds.token, err = required("SLACK_MCP_XOXP_TOKEN"); err != nil {
+			return creds, err
+		}
+	case AuthModeXoxb:
+		if creds.token, err = required("SLACK_MCP_XOXB_TOKEN"); err != nil {
+			return creds, err
+		}
+	default:
+		return creds, fmt.Errorf("invalid %s %q: allowed values are 'xoxc', 'xoxp' and 'xoxb'", describe("SLACK_MCP_AUTH_MODE"), mode)
+	}
+
+	if prefix := string(mode) + "-"; !strings.HasPrefix(creds.token, prefix) {
+		log.Printf("Warning: token for auth mode %s does not start with %q", mode, prefix)
+	}
+
+	return creds, nil
+}
+
+// AuthMode returns the kind of credentials the provider authenticates with.
+func (ap *ApiProvider) AuthMode() AuthMode {
+	return ap.authMode
+}
+
+// DescribeError adds a hint to Slack API errors caused by the token type or
+// missing OAuth scopes, which happen mostly with xoxb and xoxp tokens.
+func (ap *ApiProvider) DescribeError(err error) error {
+	var slackErr slack.SlackErrorResponse
+	if !errors.As(err, &slackErr) {
+		return err
+	}
+
+	switch slackErr.Err {
+	case "missing_scope":
+		return fmt.Errorf("the %s token lacks an OAuth scope required by this tool, add it to the Slack app and reinstall it: %w", ap.authMode, err)
+	case "not_allowed_token_type":
+		return fmt.Errorf("this tool is not available with %s tokens, use a user token (xoxp or xoxc) instead: %w", ap.authMode, err)
+	case "not_in_channel":
+		return fmt.Errorf("the %s token is not a member of this channel, invite the app to the channel first: %w", ap.authMode, err)
+	default:
+		return err
+	}
+}
+
+// isScopeError reports whether err is caused by the token lacking access
+// to an API method rather than by a transient failure.
+func isScopeError(err error) bool {
+	var slackErr slack.SlackErrorResponse
+	if !errors.As(err, &slackErr) {
+		return false
+	}
+
+	return slackErr.Err == "missing_scope" || slackErr.Err == "not_allowed_token_type"
+}
@@ -0,0 +1,67 @@
+package provider
+
+import "testing"
+
+func TestCredentialsFromEnv(t *testing.T) {
+	tests := []struct {
+		name     string
+		env      map[string]string
+		wantMode AuthMode
+		wantErr  bool
+	}{
+		{
+			name:     "Browser session",
+			env:      map[string]string{"SLACK_MCP_XOXC_TOKEN": "xoxc-1", "SLACK_MCP_XOXD_TOKEN": "xoxd-1"},
+			wantMode: AuthModeXoxc,
+		},
+		{
+			name:     "Browser session without cookie",
+			env:      map[string]string{"SLACK_MCP_XOXC_TOKEN": "xoxc-1"},
+			wantMode: AuthModeXoxc,
+			wantErr:  true,
+		},
+		{
+			name:     "User token is detected",
+			env:      map[string]string{"SLACK_MCP_XOXP_TOKEN": "xoxp-1"},
+			wantMode: AuthModeXoxp,
+		},
+		{
+			name:     "Bot token is detected",
+			env:      map[string]string{"SLACK_MCP_XOXB_TOKEN": "xoxb-1"},
+			wantMode: AuthModeXoxb,
+		},
+		{
+			name:     "Explicit mode wins over detection",
+			env:      map[string]string{"SLACK_MCP_AUTH_MODE": "xoxb", "SLACK_MCP_XOXP_TOKEN": "xoxp-1", "SLACK_MCP_XOXB_TOKEN": "xoxb-1"},
+			wantMode: AuthModeXoxb,
+		},
+		{
+			name:     "Explicit mode without token",
+			env:      map[string]string{"SLACK_MCP_AUTH_MODE": "xoxp"},
+			wantMode: AuthModeXoxp,
+			wantErr:  true,
+		},
+		{
+			name:     "Invalid mode",
+			env:      map[string]string{"SLACK_MCP_AUTH_MODE": "oauth"},
+			wantMode: "oauth",
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, name := range []string{"SLACK_MCP_AUTH_MODE", "SLACK_MCP_XOXC_TOKEN", "SLACK_MCP_XOXD_TOKEN", "SLACK_MCP_XOXP_TOKEN", "SLACK_MCP_XOXB_TOKEN"} {
+				t.Setenv(name, tt.env[name])
+			}
+
+			creds, err := credentialsFromEnv()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("credentialsFromEnv() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if creds.mode != tt.wantMode {
+				t.Errorf("credentialsFromEnv() mode = %s, want %s", creds.mode, tt.wantMode)
+			}
+		})
+	}
+}
@@ -0,0 +1,458 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/slack-go/slack"
+)
+
+const (
+	defaultChannelsCacheTTL        = 24 * time.Hour
+	defaultChannelsRefreshInterval = time.Hour
+
+	// channelsPageSize is the number of channels requested per
+	// conversations.list page.
+	channelsPageSize = 1000
+)
+
+// ErrNoDirectMessage is returned by LookupChannel for a user who has no
+// direct message with the authenticated user yet.
+var ErrNoDirectMessage = errors.New("no direct message with this user yet")
+
+// channelTypes are the conversation types kept in the channel directory.
+// They are listed one type at a time so that a token lacking the scope of
+// one type still gets the others.
+var channelTypes = []string{"public_channel", "private_channel", "mpim", "im"}
+
+// channelDirectory is an immutable snapshot of the known channels with
+// indexes by name and by the user of direct messages.
+type channelDirectory struct {
+	byID     map[string]slack.Channel
+	byName   map[string]string
+	imByUser map[string]string
+	loadedAt time.Time
+}
+
+// ChannelType returns the conversations.list type of the channel:
+// "public_channel", "private_channel", "mpim" or "im".
+func ChannelType(channel slack.Channel) string {
+	switch {
+	case channel.IsIM:
+		return "im"
+	case channel.IsMpIM:
+		return "mpim"
+	case channel.IsPrivate || channel.IsGroup:
+		return "private_channel"
+	default:
+		return "public_channel"
+	}
+}
+
+var emptyChannelDirectory = &channelDirectory{}
+
+// channelDirectory returns the current snapshot of the channel directory,
+// including the channels fetched on demand since the last snapshot.
+func (ap *ApiProvider) channelDirectory() *channelDirectory {
+	ap.channelsMu.RLock()
+	dir, pending := ap.channels, len(ap.channelsPending)
+	ap.channelsMu.RUnlock()
+
+	if pending > 0 {
+		ap.channelsMu.Lock()
+		ap.mergeChannelsLocked(nil, time.Time{})
+		dir = ap.channels
+		ap.channelsMu.Unlock()
+	}
+
+	if dir == nil {
+		return emptyChannelDirectory
+	}
+
+	return dir
+}
+
+// lookupChannel returns the channel with the given ID from the directory
+// or the pending channels.
+func (ap *ApiProvider) lookupChannel(id string) (slack.Channel, bool) {
+	ap.channelsMu.RLock()
+	defer ap.channelsMu.RUnlock()
+
+	if ap.channels != nil {
+		if channel, ok := ap.channels.byID[id]; ok {
+			return channel, true
+		}
+	}
+	channel, ok := ap.channelsPending[id]
+
+	return channel, ok
+}
+
+// addChannel records a channel fetched on demand without rebuilding the
+// directory. It becomes part of the snapshot the next time the directory
+// is read or merged.
+func (ap *ApiProvider) addChannel(channel slack.Channel) {
+	ap.channelsMu.Lock()
+	defer ap.channelsMu.Unlock()
+
+	if ap.channelsPending == nil {
+		ap.channelsPending = make(map[string]slack.Channel)
+	}
+	ap.channelsPending[channel.ID] = channel
+}
+
+// mergeChannels publishes a new directory built from the current one, the
+// pending channels and the given channels, which win over existing
+// entries. A non-zero loadedAt marks the result of a complete listing.
+func (ap *ApiProvider) mergeChannels(channels []slack.Channel, loadedAt time.Time) {
+	ap.channelsMu.Lock()
+	defer ap.channelsMu.Unlock()
+
+	ap.mergeChannelsLocked(channels, loadedAt)
+}
+
+func (ap *ApiProvider) mergeChannelsLocked(channels []slack.Channel, loadedAt time.Time) {
+	current := ap.channels
+	if current == nil {
+		current = emptyChannelDirectory
+	}
+
+	dir := &channelDirectory{
+		byID:     make(map[string]slack.Channel, len(current.byID)+len(ap.channelsPending)+len(channels)),
+		byName:   make(map[string]string, len(current.byName)+len(channels)),
+		imByUser: make(map[string]string, len(current.imByUser)),
+		loadedAt: current.loadedAt,
+	}
+	if !loadedAt.IsZero() {
+		dir.loadedAt = loadedAt
+	}
+
+	for id, channel := range current.byID {
+		dir.byID[id] = channel
+	}
+	for id, channel := range ap.channelsPending {
+		dir.byID[id] = channel
+	}
+	for _, channel := range channels {
+		dir.byID[channel.ID] = channel
+	}
+
+	for id, channel := range dir.byID {
+		if channel.IsIM {
+			dir.imByUser[channel.User] = id
+			continue
+		}
+		if channel.Name == "" {
+			continue
+		}
+		// Prefer active channels when an archived one has the same name.
+		name := strings.ToLower(channel.Name)
+		if other, ok := dir.byName[name]; ok && !dir.byID[other].IsArchived {
+			continue
+		}
+		dir.byName[name] = id
+	}
+
+	ap.channels = dir
+	ap.channelsPending = nil
+}
+
+// ProvideChannels returns every channel of the directory, loading it on
+// first use.
+func (ap *ApiProvider) ProvideChannels(ctx context.Context) ([]slack.Channel, error) {
+	if err := ap.ensureChannels(ctx); err != nil {
+		return nil, err
+	}
+
+	dir := ap.channelDirectory()
+	channels := make([]slack.Channel, 0, len(dir.byID))
+	for _, channel := range dir.byID {
+		channels = append(channels, channel)
+	}
+
+	return channels, nil
+}
+
+var (
+	channelIDRe = regexp.MustCompile(`^[CGD][A-Z0-9]{8,}$`)
+	userIDRe    = regexp.MustCompile(`^[UW][A-Z0-9]{8,}$`)
+)
+
+// IsChannelID reports whether s has the form of a conversation ID.
+func IsChannelID(s string) bool {
+	return channelIDRe.MatchString(s)
+}
+
+// IsUserID reports whether s has the form of a user ID.
+func IsUserID(s string) bool {
+	return userIDRe.MatchString(s)
+}
+
+// ResolveChannel turns a channel reference into a channel ID. References
+// are channel IDs, channel names with or without a leading "#", message
+// permalinks, or user names and IDs prefixed with "@" for the direct
+// message with that user, which is opened if it does not exist yet.
+func (ap *ApiProvider) ResolveChannel(ctx context.Context, ref string) (string, error) {
+	return ap.resolveChannel(ctx, ref, true)
+}
+
+// LookupChannel resolves ref like ResolveChannel, but never opens a direct
+// message. For a user without a direct message yet it returns an error
+// wrapping ErrNoDirectMessage.
+func (ap *ApiProvider) LookupChannel(ctx context.Context, ref string) (string, error) {
+	return ap.resolveChannel(ctx, ref, false)
+}
+
+func (ap *ApiProvider) resolveChannel(ctx context.Context, ref string, open bool) (string, error) {
+	ref = strings.TrimSpace(ref)
+
+	if permalink, ok := ParsePermalink(ref); ok {
+		return permalink.Channel, nil
+	}
+	if user, ok := strings.CutPrefix(ref, "@"); ok {
+		return ap.resolveIM(ctx, user, open)
+	}
+	if channelIDRe.MatchString(ref) {
+		return ref, nil
+	}
+
+	name := strings.ToLower(strings.TrimPrefix(ref, "#"))
+	if name == "" {
+		return "", fmt.Errorf("invalid channel %q", ref)
+	}
+	if id, ok := ap.channelDirectory().byName[name]; ok {
+		return id, nil
+	}
+	if err := ap.ensureChannels(ctx); err != nil {
+		return "", fmt.Errorf("failed to resolve channel %q: %w", ref, err)
+	}
+	if id, ok := ap.channelDirectory().byName[name]; ok {
+		return id, nil
+	}
+
+	return "", fmt.Errorf("channel %q not found", ref)
+}
+
+// resolveIM returns the ID of the direct message with the user. When the
+// user has no direct message yet it is opened if open is set.
+func (ap *ApiProvider) resolveIM(ctx context.Context, ref string, open bool) (string, error) {
+	userID := ref
+	if !userIDRe.MatchString(ref) {
+		user, err := ap.UserByName(ref)
+		if err != nil {
+			return "", err
+		}
+		userID = user.ID
+	}
+
+	if id, ok := ap.channelDirectory().imByUser[userID]; ok {
+		return id, nil
+	}
+	if err := ap.ensureChannels(ctx); err != nil {
+		// Opening the conversation below does not need the directory.
+		log.Printf("Failed to load channels while resolving @%s: %v", ref, err)
+	}
+	if id, ok := ap.channelDirectory().imByUser[userID]; ok {
+		return id, nil
+	}
+
+	if !open {
+		return "", fmt.Errorf("%w: %q", ErrNoDirectMessage, "@"+ref)
+	}
+
+	client, err := ap.Provide()
+	if err != nil {
+		return "", err
+	}
+
+	channel, _, _, err := client.OpenConversationContext(ctx, &slack.OpenConversationParameters{
+		Users:    []string{userID},
+		ReturnIM: true,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to open direct message with %q: %w", "@"+ref, err)
+	}
+
+	im := *channel
+	im.IsIM = true
+	im.User = userID
+	ap.addChannel(im)
+
+	return im.ID, nil
+}
+
+// ensureChannels loads the channel directory from the disk cache or the
+// API unless it was loaded before.
+func (ap *ApiProvider) ensureChannels(ctx context.Context) error {
+	if !ap.channelDirectory().loadedAt.IsZero() {
+		return nil
+	}
+
+	client, err := ap.Provide()
+	if err != nil {
+		return err
+	}
+
+	ap.channelsRefreshMu.Lock()
+	defer ap.channelsRefreshMu.Unlock()
+
+	if !ap.channelDirectory().loadedAt.IsZero() || ap.loadChannelsCache() {
+		return nil
+	}
+
+	return ap.refreshChannelsLocked(ctx, client)
+}
+
+// loadChannelsCache populates the channel directory from the on-disk cache.
+// It reports false when caching is disabled, the file is missing or
+// unreadable, or the file is older than the configured TTL.
+func (ap *ApiProvider) loadChannelsCache() bool {
+	if ap.channelsCache == "" {
+		return false
+	}
+
+	info, err := os.Stat(ap.channelsCache)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Printf("Failed to stat cache file %s: %v; will refetch", ap.channelsCache, err)
+		}
+		return false
+	}
+
+	if age := time.Since(info.ModTime()); ap.channelsCacheTTL > 0 && age > ap.channelsCacheTTL {
+		log.Printf("Cache file %s is %s old, older than TTL %s; will refetch", ap.channelsCache, age.Round(time.Second), ap.channelsCacheTTL)
+		return false
+	}
+
+	data, err := os.ReadFile(ap.channelsCache)
+	if err != nil {
+		log.Printf("Failed to read cache file %s: %v; will refetch", ap.channelsCache, err)
+		return false
+	}
+
+	var cachedChannels []slack.Channel
+	if err := json.Unmarshal(data, &cachedChannels); err != nil {
+		log.Printf("Failed to unmarshal %s: %v; will refetch", ap.channelsCache, err)
+		return false
+	}
+
+	ap.mergeChannels(cachedChannels, info.ModTime())
+	log.Printf("Loaded %d channels from cache %q", len(cachedChannels), ap.channelsCache)
+
+	return true
+}
+
+// RefreshChannels fetches every channel visible to the token, merges them
+// into the channel directory and rewrites the on-disk cache when enabled.
+func (ap *ApiProvider) RefreshChannels(ctx context.Context) error {
+	client, err := ap.Provide()
+	if err != nil {
+		return err
+	}
+
+	// The lock is shared with ensureChannels, so that a first channel
+	// lookup arriving during a refresh waits for the directory instead of
+	// listing every channel type a second time.
+	ap.channelsRefreshMu.Lock()
+	defer ap.channelsRefreshMu.Unlock()
+
+	return ap.refreshChannelsLocked(ctx, client)
+}
+
+func (ap *ApiProvider) refreshChannelsLocked(ctx context.Context, client *slack.Client) error {
+	log.Printf("Fetching channels from API...")
+
+	var channels []slack.Channel
+	for _, channelType := range channelTypes {
+		fetched, err := fetchChannels(ctx, client, channelType)
+		if isScopeError(err) {
+			log.Printf("Channels of type %s are not available with the %s token, skipping them: %v", channelType, ap.authMode, err)
+			continue
+		}
+		if err != nil {
+			log.Printf("Failed to fetch channels: %v", err)
+			return err
+		}
+		channels = append(channels, fetched...)
+	}
+
+	ap.mergeChannels(channels, time.Now())
+
+	log.Printf("Fetched %d channels from API", len(channels))
+
+	if ap.channelsCache != "" {
+		if data, err := json.Marshal(channels); err != nil {
+			log.Printf("Failed to marshal channels for cache: %v", err)
+		} else {
+			if err := os.WriteFile(ap.channelsCache, data, 0644); err != nil {
+				log.Printf("Failed to write cache file %q: %v", ap.channelsCache, err)
+			} else {
+				log.Printf("Wrote %d channels to cache %q", len(channels), ap.channelsCache)
+			}
+		}
+	}
+
+	return nil
+}
+
+// fetchChannels walks every page of conversations.list for one channel
+// type, including archived channels.
+func fetchChannels(ctx context.Context, client *slack.Client, channelType string) ([]slack.Channel, error) {
+	var channels []slack.Channel
+
+	params := &slack.GetConversationsParameters{
+		Types: []string{channelType},
+		Limit: channelsPageSize,
+	}
+	for {
+		page, cursor, err := client.GetConversationsContext(ctx, params)
+		if err != nil {
+			return nil, err
+		}
+		channels = append(channels, page...)
+
+		if cursor == "" {
+			return channels, nil
+		}
+		params.Cursor = cursor
+	}
+}
+
+// StartChannelsRefresher loads the channel directory and refreshes it every
+// refresh interval until the context is cancelled. With a zero interval the
+// directory is only loaded once.
+func (ap *ApiProvider) StartChannelsRefresher(ctx context.Context) {
+	go func() {
+		if err := ap.ensureChannels(ctx); err != nil {
+			log.Printf("Loading channels failed: %v", err)
+		}
+
+		if ap.channelsRefreshInt <= 0 {
+			log.Printf("Periodic channels refresh is DISABLED.")
+			return
+		}
+
+		log.Printf("Refreshing channels every %s", ap.channelsRefreshInt)
+
+		ticker := time.NewTicker(ap.channelsRefreshInt)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := ap.RefreshChannels(ctx); err != nil {
+					log.Printf("Periodic channels refresh failed: %v", err)
+				}
+			}
+		}
+	}()
+}
@@ -0,0 +1,120 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/slack-go/slack"
+)
+
+func testChannel(id, name string) slack.Channel {
+	var channel slack.Channel
+	channel.ID = id
+	channel.Name = name
+	channel.IsChannel = true
+	return channel
+}
+
+func testIM(id, user string) slack.Channel {
+	var channel slack.Channel
+	channel.ID = id
+	channel.IsIM = true
+	channel.User = user
+	return channel
+}
+
+func TestResolveChannel(t *testing.T) {
+	ctx := context.Background()
+	ap, fake, _ := newTestProvider(t, 3)
+	ap.channelsCache = filepath.Join(t.TempDir(), "channels.json")
+
+	archived := testChannel("C000000009", "general")
+	archived.IsArchived = true
+	fake.channels = []slack.Channel{
+		archived,
+		testChannel("C000000001", "general"),
+		testChannel("C000000002", "Random"),
+		testIM("D000000001", "U001"),
+	}
+
+	tests := []struct {
+		ref     string
+		want    string
+		wantErr bool
+	}{
+		{ref: "C000000002", want: "C000000002"},
+		{ref: "#general", want: "C000000001"},
+		{ref: "#random", want: "C000000002"},
+		{ref: "random", want: "C000000002"},
+		{ref: "https://acme.slack.com/archives/C000000007/p1712345678123456", want: "C000000007"},
+		{ref: "@user1", want: "D000000001"},
+		{ref: "@U000000001", want: "DU000000001"},
+		{ref: "@user2", want: "DU002"},
+		{ref: "#missing", wantErr: true},
+		{ref: "#", wantErr: true},
+		{ref: "@nobody", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ref, func(t *testing.T) {
+			got, err := ap.ResolveChannel(ctx, tt.ref)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ResolveChannel(%q) error = %v, wantErr %v", tt.ref, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ResolveChannel(%q) = %q, want %q", tt.ref, got, tt.want)
+			}
+		})
+	}
+
+	// Misses must not list the workspace again once it was loaded.
+	if n := fake.count("conversations.list"); n != int64(len(channelTypes)) {
+		t.Errorf("conversations.list called %d times, want %d", n, len(channelTypes))
+	}
+
+	// Opened direct messages are added to the directory.
+	if _, err := ap.ResolveChannel(ctx, "@user2"); err != nil {
+		t.Fatal(err)
+	}
+	if n := fake.count("conversations.open"); n != 2 {
+		t.Errorf("conversations.open called %d times, want 2", n)
+	}
+
+	// Lookups never open direct messages.
+	if got, err := ap.LookupChannel(ctx, "@user1"); err != nil || got != "D000000001" {
+		t.Errorf("LookupChannel(@user1) = %q, %v", got, err)
+	}
+	if _, err := ap.LookupChannel(ctx, "@U000000009"); !errors.Is(err, ErrNoDirectMessage) {
+		t.Errorf("LookupChannel(@U000000009) error = %v, want ErrNoDirectMessage", err)
+	}
+	if n := fake.count("conversations.open"); n != 2 {
+		t.Errorf("conversations.open called %d times after lookups, want 2", n)
+	}
+
+	// A second provider loads the directory from the disk cache.
+	cached, fake2, _ := newTestProvider(t, 0)
+	cached.channelsCache = ap.channelsCache
+	cached.channelsCacheTTL = 0
+	if got, err := cached.ResolveChannel(ctx, "#random"); err != nil || got != "C000000002" {
+		t.Errorf("ResolveChannel from cache = %q, %v", got, err)
+	}
+	if n := fake2.count("conversations.list"); n != 0 {
+		t.Errorf("conversations.list called %d times with a fresh cache, want 0", n)
+	}
+}
+
+func TestRefreshChannelsSkipsMissingScopes(t *testing.T) {
+	ap, fake, _ := newTestProvider(t, 0)
+	fake.channels = []slack.Channel{testChannel("C000000001", "general"), testIM("D000000001", "U001")}
+	fake.noScope = map[string]bool{"im": true, "mpim": true}
+
+	channels, err := ap.ProvideChannels(context.Background())
+	if err != nil {
+		t.Fatalf("ProvideChannels() error = %v", err)
+	}
+	if len(channels) != 1 || channels[0].ID != "C000000001" {
+		t.Errorf("ProvideChannels() = %v, want only the public channel", channels)
+	}
+}
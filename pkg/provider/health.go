@@ -0,0 +1,86 @@
+package provider
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/slack-go/slack"
+)
+
+const (
+	HealthStarting = "starting"
+	HealthReady    = "ready"
+	HealthFailed   = "failed"
+)
+
+// Health describes the boot state of the provider.
+type Health struct {
+	Status      string    `json:"status"`
+	AuthMode    string    `json:"authMode"`
+	Error       string    `json:"error"`
+	Team        string    `json:"team"`
+	User        string    `json:"user"`
+	Users       int       `json:"users"`
+	LastAttempt time.Time `json:"lastAttempt"`
+	BootedAt    time.Time `json:"bootedAt"`
+	Requests    int64     `json:"requests"`
+	Retries     int64     `json:"retries"`
+	RateLimited int64     `json:"rateLimited"`
+	BudgetWaits int64     `json:"budgetWaits"`
+}
+
+// Health returns the current boot state of the provider.
+func (ap *ApiProvider) Health() Health {
+	ap.healthMu.RLock()
+	health := ap.health
+	ap.healthMu.RUnlock()
+
+	health.Users = len(ap.ProvideUsersMap())
+	if retry := ap.retry.Load(); retry != nil {
+		stats := retry.Stats()
+		health.Requests = stats.Requests
+		health.Retries = stats.Retries
+		health.RateLimited = stats.RateLimited
+		health.BudgetWaits = stats.BudgetWaits
+	}
+
+	return health
+}
+
+func (ap *ApiProvider) setHealth(status string, err error) {
+	ap.healthMu.Lock()
+	defer ap.healthMu.Unlock()
+
+	now := time.Now()
+	ap.health.Status = status
+	ap.health.LastAttempt = now
+	ap.health.Error = ""
+	if err != nil {
+		ap.health.Error = err.Error()
+	}
+	if status == HealthReady {
+		ap.health.BootedAt = now
+	}
+}
+
+// describeAuthError turns the errors Slack returns for rejected credentials
+// into a message that tells the user what to fix.
+func describeAuthError(mode AuthMode, err error) error {
+	var slackErr slack.SlackErrorResponse
+	if !errors.As(err, &slackErr) {
+		return fmt.Errorf("failed to authenticate with Slack: %w", err)
+	}
+
+	switch slackErr.Err {
+	case "invalid_auth", "not_authed", "token_expired", "token_revoked", "account_inactive":
+		if mode == AuthModeXoxc {
+			return fmt.Errorf("credentials rejected by Slack (%s): the token may have expired or the session cookie is invalid, "+
+				"obtain fresh SLACK_MCP_XOXC_TOKEN and SLACK_MCP_XOXD_TOKEN values and restart the server: %w", slackErr.Err, err)
+		}
+		return fmt.Errorf("credentials rejected by Slack (%s): the %s token is invalid or was revoked, "+
+			"reinstall the Slack app to obtain a new one and restart the server: %w", slackErr.Err, mode, err)
+	default:
+		return fmt.Errorf("failed to authenticate with Slack: %w", err)
+	}
+}
@@ -0,0 +1,44 @@
+package provider
+
+import (
+	"net/url"
+	"strings"
+)
+
+// Permalink is a link to a Slack message, such as
+// https://acme.slack.com/archives/C0123456789/p1712345678123456?thread_ts=1712345000.000100&cid=C0123456789
+type Permalink struct {
+	Channel  string
+	Ts       string
+	ThreadTs string
+}
+
+// ParsePermalink parses a message permalink. Links to a channel without a
+// message are accepted and leave Ts empty.
+func ParsePermalink(link string) (Permalink, bool) {
+	u, err := url.Parse(strings.TrimSpace(link))
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") {
+		return Permalink{}, false
+	}
+
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if len(parts) < 2 || len(parts) > 3 || parts[0] != "archives" || parts[1] == "" {
+		return Permalink{}, false
+	}
+
+	p := Permalink{
+		Channel:  parts[1],
+		ThreadTs: u.Query().Get("thread_ts"),
+	}
+	if len(parts) == 3 {
+		// Message timestamps are encoded as "p" followed by the digits of
+		// the timestamp without its dot, the last six being microseconds.
+		digits, ok := strings.CutPrefix(parts[2], "p")
+		if !ok || len(digits) <= 6 || strings.Trim(digits, "0123456789") != "" {
+			return Permalink{}, false
+		}
+		p.Ts = digits[:len(digits)-6] + "." + digits[len(digits)-6:]
+	}
+
+	return p, true
+}
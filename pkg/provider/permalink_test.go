@@ -0,0 +1,40 @@
+package provider
+
+import "testing"
+
+func TestParsePermalink(t *testing.T) {
+	tests := []struct {
+		link   string
+		want   Permalink
+		wantOK bool
+	}{
+		{
+			link:   "https://acme.slack.com/archives/C0123456789/p1712345678123456",
+			want:   Permalink{Channel: "C0123456789", Ts: "1712345678.123456"},
+			wantOK: true,
+		},
+		{
+			link:   "https://acme.slack.com/archives/C0123456789/p1712345678123456?thread_ts=1712345000.000100&cid=C0123456789",
+			want:   Permalink{Channel: "C0123456789", Ts: "1712345678.123456", ThreadTs: "1712345000.000100"},
+			wantOK: true,
+		},
+		{
+			link:   "https://acme.slack.com/archives/C0123456789",
+			want:   Permalink{Channel: "C0123456789"},
+			wantOK: true,
+		},
+		{link: "https://acme.slack.com/archives/C0123456789/x1712345678123456"},
+		{link: "https://acme.slack.com/team/U0123456789"},
+		{link: "#general"},
+		{link: "C0123456789"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.link, func(t *testing.T) {
+			got, ok := ParsePermalink(tt.link)
+			if ok != tt.wantOK || got != tt.want {
+				t.Errorf("ParsePermalink() = %+v, %v, want %+v, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
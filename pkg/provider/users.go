@@ -0,0 +1,307 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/slack-go/slack"
+)
+
+const (
+	defaultUsersCacheTTL        = 24 * time.Hour
+	defaultUsersRefreshInterval = time.Hour
+
+	// usersPageSize is the number of users requested per users.list page.
+	usersPageSize = 1000
+)
+
+// loadUsersCache populates the users map from the on-disk cache. It reports
+// false when caching is disabled, the file is missing or unreadable, or the
+// file is older than the configured TTL.
+func (ap *ApiProvider) loadUsersCache() bool {
+	if ap.usersCache == "" {
+		return false
+	}
+
+	info, err := os.Stat(ap.usersCache)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Printf("Failed to stat cache file %s: %v; will refetch", ap.usersCache, err)
+		}
+		return false
+	}
+
+	if age := time.Since(info.ModTime()); ap.usersCacheTTL > 0 && age > ap.usersCacheTTL {
+		log.Printf("Cache file %s is %s old, older than TTL %s; will refetch", ap.usersCache, age.Round(time.Second), ap.usersCacheTTL)
+		return false
+	}
+
+	data, err := os.ReadFile(ap.usersCache)
+	if err != nil {
+		log.Printf("Failed to read cache file %s: %v; will refetch", ap.usersCache, err)
+		return false
+	}
+
+	var cachedUsers []slack.User
+	if err := json.Unmarshal(data, &cachedUsers); err != nil {
+		log.Printf("Failed to unmarshal %s: %v; will refetch", ap.usersCache, err)
+		return false
+	}
+
+	loaded := make(map[string]slack.User, len(cachedUsers))
+	for _, u := range cachedUsers {
+		loaded[u.ID] = u
+	}
+	ap.mergeUsers(loaded, true)
+	log.Printf("Loaded %d users from cache %q", len(cachedUsers), ap.usersCache)
+
+	return true
+}
+
+// UserByName returns the active user whose handle is name, ignoring case.
+// Display names are not unique, so they are only used when no handle
+// matches and exactly one user has that display name; otherwise the error
+// lists the candidates.
+func (ap *ApiProvider) UserByName(name string) (slack.User, error) {
+	var candidates []slack.User
+	for _, user := range ap.ProvideUsersMap() {
+		if user.Deleted {
+			continue
+		}
+		if strings.EqualFold(user.Name, name) {
+			return user, nil
+		}
+		if strings.EqualFold(user.Profile.DisplayName, name) {
+			candidates = append(candidates, user)
+		}
+	}
+
+	switch len(candidates) {
+	case 0:
+		return slack.User{}, fmt.Errorf("user %q not found", "@"+name)
+	case 1:
+		return candidates[0], nil
+	}
+
+	names := make([]string, 0, len(candidates))
+	for _, user := range candidates {
+		names = append(names, fmt.Sprintf("@%s (%s, %s)", user.Name, user.ID, user.RealName))
+	}
+	sort.Strings(names)
+
+	return slack.User{}, fmt.Errorf("ambiguous user %q, candidates: %s", "@"+name, strings.Join(names, ", "))
+}
+
+// RefreshUsers fetches the complete list of workspace users, replaces the
+// users map with it and rewrites the on-disk cache when enabled. Users that
+// were fetched on demand but are not part of the workspace list, such as
+// Slack Connect guests, are kept.
+func (ap *ApiProvider) RefreshUsers(ctx context.Context) error {
+	client, err := ap.Provide()
+	if err != nil {
+		return err
+	}
+
+	return ap.refreshUsers(ctx, client)
+}
+
+// RequestUsersRefresh starts RefreshUsers in the background unless a
+// requested refresh is still running. It is used by handlers that find
+// many unknown users at once, instead of fetching them one by one.
+func (ap *ApiProvider) RequestUsersRefresh() {
+	if !ap.usersRefreshing.CompareAndSwap(false, true) {
+		return
+	}
+
+	go func() {
+		defer ap.usersRefreshing.Store(false)
+
+		if err := ap.RefreshUsers(context.Background()); err != nil {
+			log.Printf("Requested users refresh failed: %v", err)
+		}
+	}()
+}
+
+func (ap *ApiProvider) refreshUsers(ctx context.Context, client *slack.Client) error {
+	// The boot fetch, the periodic refresher and the refreshes requested
+	// on cache misses all end up here. Holding the lock for the whole pass
+	// keeps them from paging through users.list concurrently and from
+	// replacing the map with an older list.
+	ap.usersRefreshMu.Lock()
+	defer ap.usersRefreshMu.Unlock()
+
+	log.Printf("Fetching users from API...")
+
+	users, err := fetchUsers(ctx, client)
+	if err != nil {
+		log.Printf("Failed to fetch users: %v", err)
+		return err
+	}
+
+	fresh := make(map[string]slack.User, len(users))
+	for _, user := range users {
+		fresh[user.ID] = user
+	}
+	ap.mergeUsers(fresh, true)
+
+	log.Printf("Fetched %d users from API", len(users))
+
+	// Attempt to write to cache only if caching is enabled (usersCache is not empty)
+	if ap.usersCache != "" {
+		if data, err := json.MarshalIndent(users, "", "  "); err != nil {
+			log.Printf("Failed to marshal users for cache: %v", err)
+		} else {
+			if err := os.WriteFile(ap.usersCache, data, 0644); err != nil {
+				log.Printf("Failed to write cache file %q: %v", ap.usersCache, err)
+			} else {
+				log.Printf("Wrote %d users to cache %q", len(users), ap.usersCache)
+			}
+		}
+	}
+
+	return nil
+}
+
+// mergeUsers publishes a new users map built from the current one, the
+// pending users and the given users. When authoritative is true the given
+// users win over the existing entries; otherwise existing entries are kept
+// as they are.
+func (ap *ApiProvider) mergeUsers(users map[string]slack.User, authoritative bool) {
+	ap.usersMu.Lock()
+	defer ap.usersMu.Unlock()
+
+	ap.mergeUsersLocked(users, authoritative)
+}
+
+func (ap *ApiProvider) mergeUsersLocked(users map[string]slack.User, authoritative bool) {
+	merged := make(map[string]slack.User, len(ap.users)+len(ap.usersPending)+len(users))
+	for id, user := range ap.users {
+		merged[id] = user
+	}
+	for id, user := range ap.usersPending {
+		merged[id] = user
+	}
+	for id, user := range users {
+		if _, ok := merged[id]; ok && !authoritative {
+			continue
+		}
+		merged[id] = user
+	}
+
+	ap.users = merged
+	ap.usersPending = nil
+}
+
+// lookupUser returns the user with the given ID from the users map or the
+// pending users.
+func (ap *ApiProvider) lookupUser(id string) (slack.User, bool) {
+	ap.usersMu.RLock()
+	defer ap.usersMu.RUnlock()
+
+	if user, ok := ap.users[id]; ok {
+		return user, true
+	}
+	user, ok := ap.usersPending[id]
+
+	return user, ok
+}
+
+// addUser records a user fetched on demand without copying the users map.
+// It becomes part of the snapshot the next time the map is read or merged.
+func (ap *ApiProvider) addUser(user slack.User) {
+	ap.usersMu.Lock()
+	defer ap.usersMu.Unlock()
+
+	if _, ok := ap.users[user.ID]; ok {
+		return
+	}
+	if ap.usersPending == nil {
+		ap.usersPending = make(map[string]slack.User)
+	}
+	ap.usersPending[user.ID] = user
+}
+
+// fetchUsers walks every page of users.list, waiting out rate limits
+// between pages, so that workspaces larger than a single page are loaded
+// completely.
+func fetchUsers(ctx context.Context, client *slack.Client) ([]slack.User, error) {
+	var users []slack.User
+
+	page := client.GetUsersPaginated(slack.GetUsersOptionLimit(usersPageSize))
+	for {
+		// Keep the previous page on failure, Next may return a page that
+		// looks exhausted when the very first request fails.
+		next, err := page.Next(ctx)
+		if rateLimitedError, ok := err.(*slack.RateLimitedError); ok {
+			log.Printf("Rate limited while fetching users, retrying in %s", rateLimitedError.RetryAfter)
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(rateLimitedError.RetryAfter):
+				continue
+			}
+		}
+		if page.Done(err) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		page = next
+		users = append(users, page.Users...)
+		log.Printf("Fetched page of %d users (%d total)", len(page.Users), len(users))
+	}
+
+	return users, nil
+}
+
+// StartUsersRefresher refreshes the users map every refresh interval until
+// the context is cancelled. It does nothing when the interval is zero.
+func (ap *ApiProvider) StartUsersRefresher(ctx context.Context) {
+	if ap.usersRefreshInt <= 0 {
+		log.Printf("Periodic users refresh is DISABLED.")
+		return
+	}
+
+	log.Printf("Refreshing users every %s", ap.usersRefreshInt)
+
+	go func() {
+		ticker := time.NewTicker(ap.usersRefreshInt)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := ap.RefreshUsers(ctx); err != nil {
+					log.Printf("Periodic users refresh failed: %v", err)
+				}
+			}
+		}
+	}()
+}
+
+// durationFromEnv parses a duration such as "30m" or "12h" from the named
+// environment variable. "0" disables the feature it configures.
+func durationFromEnv(name string, fallback time.Duration) time.Duration {
+	value := os.Getenv(name)
+	if value == "" {
+		return fallback
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		log.Printf("Invalid %s %q, using default %s", name, value, fallback)
+		return fallback
+	}
+
+	return d
+}
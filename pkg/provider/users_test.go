@@ -0,0 +1,107 @@
+package provider
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/slack-go/slack"
+)
+
+func TestLoadUsersCache(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "users.json")
+	data, err := json.Marshal([]slack.User{{ID: "U1", Name: "jane"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		path  string
+		ttl   time.Duration
+		age   time.Duration
+		want  bool
+		wantN int
+	}{
+		{name: "Caching disabled", path: "", want: false},
+		{name: "Missing file", path: filepath.Join(t.TempDir(), "missing.json"), want: false},
+		{name: "Fresh file", path: path, ttl: time.Hour, age: time.Minute, want: true, wantN: 1},
+		{name: "Expired file", path: path, ttl: time.Hour, age: 2 * time.Hour, want: false},
+		{name: "No TTL", path: path, ttl: 0, age: 1000 * time.Hour, want: true, wantN: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.path == path {
+				mtime := time.Now().Add(-tt.age)
+				if err := os.Chtimes(path, mtime, mtime); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			ap := &ApiProvider{
+				users:         make(map[string]slack.User),
+				usersCache:    tt.path,
+				usersCacheTTL: tt.ttl,
+			}
+			if got := ap.loadUsersCache(); got != tt.want {
+				t.Errorf("loadUsersCache() = %v, want %v", got, tt.want)
+			}
+			if len(ap.users) != tt.wantN {
+				t.Errorf("loaded %d users, want %d", len(ap.users), tt.wantN)
+			}
+		})
+	}
+}
+
+func TestUserByName(t *testing.T) {
+	ap := &ApiProvider{users: make(map[string]slack.User)}
+	users := map[string]slack.User{
+		"U1": {ID: "U1", Name: "alice", Profile: slack.UserProfile{DisplayName: "Al"}},
+		"U2": {ID: "U2", Name: "alan", Profile: slack.UserProfile{DisplayName: "Al"}},
+		"U3": {ID: "U3", Name: "bob", Profile: slack.UserProfile{DisplayName: "Bobby"}},
+		"U4": {ID: "U4", Name: "al", Deleted: true},
+		"U5": {ID: "U5", Name: "carol", Profile: slack.UserProfile{DisplayName: "alice"}},
+	}
+	ap.mergeUsers(users, true)
+
+	tests := []struct {
+		name    string
+		want    string
+		wantErr string
+	}{
+		{name: "alice", want: "U1"},
+		{name: "ALICE", want: "U1"},
+		{name: "bobby", want: "U3"},
+		{name: "al", wantErr: "ambiguous"},
+		{name: "nobody", wantErr: "not found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Map iteration order is random, so repeat to catch flakiness.
+			for i := 0; i < 20; i++ {
+				user, err := ap.UserByName(tt.name)
+				if tt.wantErr != "" {
+					if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+						t.Fatalf("UserByName(%q) = %v, %v; want error containing %q", tt.name, user.ID, err, tt.wantErr)
+					}
+					continue
+				}
+				if err != nil || user.ID != tt.want {
+					t.Fatalf("UserByName(%q) = %q, %v; want %q", tt.name, user.ID, err, tt.want)
+				}
+			}
+		})
+	}
+
+	if _, err := ap.UserByName("al"); err == nil || !strings.Contains(err.Error(), "@alan (U2") || !strings.Contains(err.Error(), "@alice (U1") {
+		t.Errorf("ambiguous error does not list the candidates: %v", err)
+	}
+}
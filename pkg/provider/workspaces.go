@@ -0,0 +1,184 @@
+package provider
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// DefaultWorkspace is the name of the workspace configured from the
+// SLACK_MCP_* environment variables when no workspaces file is used.
+const DefaultWorkspace = "default"
+
+// Workspaces holds one ApiProvider per configured Slack workspace. Each
+// provider has its own client, users map and channel cache.
+type Workspaces struct {
+	providers   map[string]*ApiProvider
+	names       []string
+	defaultName string
+}
+
+// workspacesFile is the format of the file referenced by
+// SLACK_MCP_WORKSPACES_CONFIG. Values may reference environment variables
+// as $VAR or ${VAR} so that tokens do not have to be stored in the file.
+type workspacesFile struct {
+	Default    string                     `json:"default"`
+	Workspaces map[string]workspaceConfig `json:"workspaces"`
+}
+
+type workspaceConfig struct {
+	AuthMode      string `json:"auth_mode"`
+	XoxcToken     string `json:"xoxc_token"`
+	XoxdToken     string `json:"xoxd_token"`
+	XoxpToken     string `json:"xoxp_token"`
+	XoxbToken     string `json:"xoxb_token"`
+	UsersCache    string `json:"users_cache"`
+	ChannelsCache string `json:"channels_cache"`
+}
+
+// NewWorkspaces creates the providers for every configured workspace. When
+// SLACK_MCP_WORKSPACES_CONFIG is not set, a single workspace named
+// "default" is configured from the environment as before.
+func NewWorkspaces() (*Workspaces, error) {
+	path := os.Getenv("SLACK_MCP_WORKSPACES_CONFIG")
+	if path == "" {
+		return NewSingleWorkspace(New()), nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read workspaces config: %w", err)
+	}
+
+	var file workspacesFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse workspaces config %s: %w", path, err)
+	}
+	if len(file.Workspaces) == 0 {
+		return nil, fmt.Errorf("workspaces config %s defines no workspaces", path)
+	}
+
+	w := &Workspaces{
+		providers: make(map[string]*ApiProvider, len(file.Workspaces)),
+	}
+
+	enableUserCache := os.Getenv("SLACK_MCP_ENABLE_USER_CACHE") == "true"
+	usersCacheTTL := durationFromEnv("SLACK_MCP_USERS_CACHE_TTL", defaultUsersCacheTTL)
+	usersRefreshInterval := durationFromEnv("SLACK_MCP_USERS_REFRESH_INTERVAL", defaultUsersRefreshInterval)
+	enableChannelCache := os.Getenv("SLACK_MCP_ENABLE_CHANNEL_CACHE") == "true"
+	channelsCacheTTL := durationFromEnv("SLACK_MCP_CHANNELS_CACHE_TTL", defaultChannelsCacheTTL)
+	channelsRefreshInterval := durationFromEnv("SLACK_MCP_CHANNELS_REFRESH_INTERVAL", defaultChannelsRefreshInterval)
+
+	for name, ws := range file.Workspaces {
+		if name == "" || strings.ContainsAny(name, " ,") {
+			return nil, fmt.Errorf("invalid workspace name %q in %s", name, path)
+		}
+
+		creds, configErr := credentialsFrom(ws.lookup, func(key string) string {
+			return fmt.Sprintf("%s of workspace %q", ws.key(key), name)
+		})
+
+		usersCache := ""
+		if enableUserCache {
+			usersCache = os.ExpandEnv(ws.UsersCache)
+			if usersCache == "" {
+				usersCache = ".users_cache_" + name + ".json"
+			}
+		}
+
+		channelsCache := ""
+		if enableChannelCache {
+			channelsCache = os.ExpandEnv(ws.ChannelsCache)
+			if channelsCache == "" {
+				channelsCache = ".channels_cache_" + name + ".json"
+			}
+		}
+
+		w.providers[name] = newProvider(config{
+			name:                    name,
+			creds:                   creds,
+			usersCache:              usersCache,
+			usersCacheTTL:           usersCacheTTL,
+			usersRefreshInterval:    usersRefreshInterval,
+			channelsCache:           channelsCache,
+			channelsCacheTTL:        channelsCacheTTL,
+			channelsRefreshInterval: channelsRefreshInterval,
+		}, configErr)
+		w.names = append(w.names, name)
+	}
+	sort.Strings(w.names)
+
+	w.defaultName = file.Default
+	if w.defaultName == "" {
+		w.defaultName = w.names[0]
+	}
+	if _, ok := w.providers[w.defaultName]; !ok {
+		return nil, fmt.Errorf("default workspace %q is not defined in %s", w.defaultName, path)
+	}
+
+	return w, nil
+}
+
+// NewSingleWorkspace wraps a single provider.
+func NewSingleWorkspace(ap *ApiProvider) *Workspaces {
+	return &Workspaces{
+		providers:   map[string]*ApiProvider{ap.Name(): ap},
+		names:       []string{ap.Name()},
+		defaultName: ap.Name(),
+	}
+}
+
+// Provider returns the provider of the named workspace, or of the default
+// workspace when name is empty.
+func (w *Workspaces) Provider(name string) (*ApiProvider, error) {
+	if name == "" {
+		name = w.defaultName
+	}
+
+	ap, ok := w.providers[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown workspace %q, available workspaces: %s", name, strings.Join(w.names, ", "))
+	}
+
+	return ap, nil
+}
+
+// All returns the providers of all workspaces ordered by name.
+func (w *Workspaces) All() []*ApiProvider {
+	providers := make([]*ApiProvider, 0, len(w.names))
+	for _, name := range w.names {
+		providers = append(providers, w.providers[name])
+	}
+
+	return providers
+}
+
+// Default returns the name of the workspace used when a tool call does not
+// name one.
+func (w *Workspaces) Default() string {
+	return w.defaultName
+}
+
+func (ws workspaceConfig) lookup(key string) string {
+	switch key {
+	case "SLACK_MCP_AUTH_MODE":
+		return os.ExpandEnv(ws.AuthMode)
+	case "SLACK_MCP_XOXC_TOKEN":
+		return os.ExpandEnv(ws.XoxcToken)
+	case "SLACK_MCP_XOXD_TOKEN":
+		return os.ExpandEnv(ws.XoxdToken)
+	case "SLACK_MCP_XOXP_TOKEN":
+		return os.ExpandEnv(ws.XoxpToken)
+	case "SLACK_MCP_XOXB_TOKEN":
+		return os.ExpandEnv(ws.XoxbToken)
+	default:
+		return ""
+	}
+}
+
+// key maps an environment variable name to its field in the config file.
+func (ws workspaceConfig) key(envKey string) string {
+	return strings.ToLower(strings.TrimPrefix(envKey, "SLACK_MCP_"))
+}
@@ -0,0 +1,107 @@
+package provider
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNewWorkspaces(t *testing.T) {
+	tests := []struct {
+		name        string
+		config      string
+		wantNames   []string
+		wantDefault string
+		wantErr     string
+	}{
+		{
+			name: "Multiple workspaces with explicit default",
+			config: `{"default": "beta", "workspaces": {
+				"beta": {"xoxp_token": "xoxp-1"},
+				"alpha": {"xoxc_token": "xoxc-1", "xoxd_token": "xoxd-1"}
+			}}`,
+			wantNames:   []string{"alpha", "beta"},
+			wantDefault: "beta",
+		},
+		{
+			name:        "First workspace is the default",
+			config:      `{"workspaces": {"zeta": {"xoxb_token": "xoxb-1"}, "eta": {"xoxb_token": "xoxb-2"}}}`,
+			wantNames:   []string{"eta", "zeta"},
+			wantDefault: "eta",
+		},
+		{
+			name:    "Unknown default",
+			config:  `{"default": "nope", "workspaces": {"alpha": {"xoxp_token": "xoxp-1"}}}`,
+			wantErr: "default workspace",
+		},
+		{
+			name:    "No workspaces",
+			config:  `{"workspaces": {}}`,
+			wantErr: "no workspaces",
+		},
+		{
+			name:    "Malformed file",
+			config:  `{"workspaces": [`,
+			wantErr: "failed to parse",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "workspaces.json")
+			if err := os.WriteFile(path, []byte(tt.config), 0600); err != nil {
+				t.Fatal(err)
+			}
+			t.Setenv("SLACK_MCP_WORKSPACES_CONFIG", path)
+
+			w, err := NewWorkspaces()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("NewWorkspaces() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NewWorkspaces() error = %v", err)
+			}
+
+			var names []string
+			for _, ap := range w.All() {
+				names = append(names, ap.Name())
+			}
+			if strings.Join(names, ",") != strings.Join(tt.wantNames, ",") {
+				t.Errorf("All() = %v, want %v", names, tt.wantNames)
+			}
+			if w.Default() != tt.wantDefault {
+				t.Errorf("Default() = %q, want %q", w.Default(), tt.wantDefault)
+			}
+
+			ap, err := w.Provider("")
+			if err != nil || ap.Name() != tt.wantDefault {
+				t.Errorf("Provider(\"\") = %v, %v, want %q", ap, err, tt.wantDefault)
+			}
+			if _, err := w.Provider("missing"); err == nil {
+				t.Errorf("Provider(\"missing\") expected error")
+			}
+		})
+	}
+}
+
+func TestWorkspaceConfigExpandsEnv(t *testing.T) {
+	t.Setenv("ACME_TOKEN", "xoxp-secret")
+
+	ws := workspaceConfig{XoxpToken: "${ACME_TOKEN}"}
+	creds, err := credentialsFrom(ws.lookup, ws.key)
+	if err != nil {
+		t.Fatalf("credentialsFrom() error = %v", err)
+	}
+	if creds.mode != AuthModeXoxp || creds.token != "xoxp-secret" {
+		t.Errorf("credentialsFrom() = %+v, want xoxp-secret", creds)
+	}
+
+	_, err = credentialsFrom(workspaceConfig{AuthMode: "xoxc"}.lookup, ws.key)
+	if err == nil || !strings.Contains(err.Error(), "xoxc_token") {
+		t.Errorf("credentialsFrom() error = %v, want mention of xoxc_token", err)
+	}
+}
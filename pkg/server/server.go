@@ -16,32 +16,203 @@ type MCPServer struct {
 	server *server.MCPServer
 }
 
-func NewMCPServer(provider *provider.ApiProvider) *MCPServer {
+func NewMCPServer(workspaces *provider.Workspaces) *MCPServer {
 	s := server.NewMCPServer(
 		"Slack MCP Server",
 		"1.0.0",
 		server.WithLogging(),
+		server.WithToolHandlerMiddleware(toolErrorMiddleware(workspaces)),
 		server.WithRecovery(),
 	)
 
-	conversationsHandler := handler.NewConversationsHandler(provider)
+	channelDescription := "Channel ID in format Cxxxxxxxxxx, channel name with or without # (e.g. #general), a message permalink, or a username prefixed with @ for the direct message with that user (e.g. @alice)"
+	channelParam := mcp.WithString("channel_id",
+		mcp.Required(),
+		mcp.Description(channelDescription),
+	)
+	formatParam := mcp.WithString("format",
+		mcp.Description("Output format. Allowed values: 'csv', 'json' - array of objects, 'jsonl' - one object per line, 'markdown' - chat transcript grouped by day and thread for messages, a table otherwise. Defaults to the server setting."),
+	)
+	workspaceParam := mcp.WithString("workspace",
+		mcp.Description("Name of the Slack workspace to use, as returned by workspaces_list. Defaults to '"+workspaces.Default()+"'."),
+	)
+	textModeParam := mcp.WithString("text_mode",
+		mcp.Description("How message text is processed. Allowed values: 'raw' - as received from Slack, 'normalized' - whitespace and entity cleanup only, 'compact' - lowercased with stopwords removed to save tokens. Defaults to the server setting."),
+	)
+
+	conversationsHandler := handler.NewConversationsHandler(workspaces)
 
 	s.AddTool(mcp.NewTool("conversations_history",
-		mcp.WithDescription("Get messages from the channel by channel_id, the last row/column in the response is used as 'cursor' parameter for pagination if not empty"),
-		mcp.WithString("channel_id",
+		mcp.WithDescription("Get messages from the channel by channel_id, newest first. Time ranges are fetched across pages up to the server's message and token budget; the last row/column in the response is used as 'cursor' parameter for pagination if not empty"),
+		channelParam,
+		mcp.WithString("cursor",
+			mcp.Description("Cursor for pagination. Use the value of the last row and column in the response as next_cursor field returned from the previous request."),
+		),
+		mcp.WithString("limit",
+			mcp.Description("Limit of messages to fetch in format of maximum ranges of time (e.g. 1d - 1 day, 30d - 30 days, 90d - 90 days which is a default limit for free tier history) or number of messages (e.g. 50). Defaults to 1d when 'oldest', 'latest' and 'cursor' are all empty. Must be empty when 'cursor' is provided. Only a number of messages is allowed together with 'oldest' or 'latest', it then caps the messages returned per call."),
+		),
+		mcp.WithString("oldest",
+			mcp.Description("Only messages after this time. Accepts a date (2026-09-01), a date and time (2026-09-01 09:30), an RFC3339 timestamp (2026-09-01T09:30:00Z), a Slack timestamp (1700000000.123456) or a duration before now (30m, 2h, 3d, 1w). Dates and times without offset use the server timezone."),
+		),
+		mcp.WithString("latest",
+			mcp.Description("Only messages before this time, in the same formats as 'oldest'. A date alone includes that whole day."),
+		),
+		textModeParam,
+		formatParam,
+		workspaceParam,
+	), conversationsHandler.ConversationsHistoryHandler)
+
+	s.AddTool(mcp.NewTool("conversations_replies",
+		mcp.WithDescription("Get a thread of messages posted to a conversation by channel_id and thread_ts, the last row/column in the response is used as 'cursor' parameter for pagination if not empty"),
+		channelParam,
+		mcp.WithString("thread_ts",
 			mcp.Required(),
-			mcp.Description("ID of the channel in format Cxxxxxxxxxx"),
+			mcp.Description("Unique identifier of either a thread's parent message or a message in the thread. ts must be the timestamp in format 1234567890.123456 of an existing message with 0 or more replies."),
 		),
 		mcp.WithString("cursor",
 			mcp.Description("Cursor for pagination. Use the value of the last row and column in the response as next_cursor field returned from the previous request."),
 		),
 		mcp.WithString("limit",
-			mcp.DefaultString("1d"),
-			mcp.Description("Limit of messages to fetch in format of maximum ranges of time (e.g. 1d - 1 day, 30d - 30 days, 90d - 90 days which is a default limit for free tier history) or number of messages (e.g. 50). Must be empty when 'cursor' is provided."),
+			mcp.DefaultString("100"),
+			mcp.Description("Limit of messages to fetch as a number of messages (e.g. 50), or as a maximum range of time (e.g. 1d - 1 day, 30d - 30 days) which drops thread messages older than that. Defaults to 100 messages from the start of the thread. Must be empty when 'cursor' is provided."),
 		),
-	), conversationsHandler.ConversationsHistoryHandler)
+		textModeParam,
+		formatParam,
+		workspaceParam,
+	), conversationsHandler.ConversationsRepliesHandler)
+
+	s.AddTool(mcp.NewTool("message_get",
+		mcp.WithDescription("Get a message by its Slack permalink, optionally with the messages around it and its thread. Messages are ordered oldest first, the requested message is the row with the permalink set."),
+		mcp.WithString("permalink",
+			mcp.Required(),
+			mcp.Description("Permalink of the message. Example: 'https://team.slack.com/archives/C0123456789/p1700000000123456'"),
+		),
+		mcp.WithNumber("context_before",
+			mcp.DefaultNumber(0),
+			mcp.Description("Number of messages before the requested one to include, between 0 and 50. For thread replies, messages of the same thread are used."),
+		),
+		mcp.WithNumber("context_after",
+			mcp.DefaultNumber(0),
+			mcp.Description("Number of messages after the requested one to include, between 0 and 50. For thread replies, messages of the same thread are used."),
+		),
+		mcp.WithBoolean("include_thread",
+			mcp.DefaultBool(false),
+			mcp.Description("If true, the replies of the message's thread are included after it. For a thread reply the whole thread is returned."),
+		),
+		textModeParam,
+		formatParam,
+		workspaceParam,
+	), conversationsHandler.MessageGetHandler)
 
-	channelsHandler := handler.NewChannelsHandler(provider)
+	s.AddTool(mcp.NewTool("pins_list",
+		mcp.WithDescription("Get the messages and files pinned to a channel, such as runbooks and key references, most recently pinned first. Each row carries the permalink of the pinned item."),
+		channelParam,
+		textModeParam,
+		formatParam,
+		workspaceParam,
+	), conversationsHandler.PinsListHandler)
+
+	s.AddTool(mcp.NewTool("conversations_add_message",
+		mcp.WithDescription("Add a message to a public channel, private channel, or direct message (DM, or IM) conversation by channel_id and thread_ts. Disabled unless SLACK_MCP_ADD_MESSAGE_TOOL is set."),
+		channelParam,
+		mcp.WithString("thread_ts",
+			mcp.Description("Unique identifier of either a thread's parent message or a message in the thread_ts must be the timestamp in format 1234567890.123456 of an existing message with 0 or more replies. Optional, if not provided the message will be added to the channel itself, otherwise it will be added to the thread."),
+		),
+		mcp.WithString("payload",
+			mcp.Required(),
+			mcp.Description("Message payload in specified content_type format. Example: 'Hello, world!' for text/plain or '# Hello, world!' for text/markdown."),
+		),
+		mcp.WithString("content_type",
+			mcp.DefaultString("text/markdown"),
+			mcp.Description("Content type of the message. Default is 'text/markdown'. Allowed values: 'text/markdown', 'text/plain'."),
+		),
+		formatParam,
+		workspaceParam,
+	), conversationsHandler.ConversationsAddMessageHandler)
+
+	s.AddTool(mcp.NewTool("reactions_add",
+		mcp.WithDescription("Add an emoji reaction to a message, e.g. to acknowledge a request. Adding a reaction that is already there is not an error. Disabled unless SLACK_MCP_ADD_MESSAGE_TOOL is set."),
+		channelParam,
+		mcp.WithString("timestamp",
+			mcp.Required(),
+			mcp.Description("Timestamp of the message in format 1234567890.123456"),
+		),
+		mcp.WithString("emoji",
+			mcp.Required(),
+			mcp.Description("Emoji name with or without colons, optionally with a skin tone. Example: 'eyes', ':white_check_mark:' or '+1::skin-tone-2'"),
+		),
+		formatParam,
+		workspaceParam,
+	), conversationsHandler.ReactionsAddHandler)
+
+	s.AddTool(mcp.NewTool("reactions_remove",
+		mcp.WithDescription("Remove an emoji reaction of the authenticated user from a message. Removing a reaction that is not there is not an error. Disabled unless SLACK_MCP_ADD_MESSAGE_TOOL is set."),
+		channelParam,
+		mcp.WithString("timestamp",
+			mcp.Required(),
+			mcp.Description("Timestamp of the message in format 1234567890.123456"),
+		),
+		mcp.WithString("emoji",
+			mcp.Required(),
+			mcp.Description("Emoji name with or without colons, optionally with a skin tone. Example: 'eyes', ':white_check_mark:' or '+1::skin-tone-2'"),
+		),
+		formatParam,
+		workspaceParam,
+	), conversationsHandler.ReactionsRemoveHandler)
+
+	s.AddTool(mcp.NewTool("search_messages",
+		mcp.WithDescription("Search messages in a public channel, private channel, or direct message (DM, or IM) conversation using filters. All filters are optional, but at least one of search_query or a filter must be provided. The last row/column in the response is used as 'cursor' parameter for pagination if not empty"),
+		mcp.WithString("search_query",
+			mcp.Description("Search query to filter messages. Example: 'marketing report'. Slack search modifiers are supported."),
+		),
+		mcp.WithString("filter_in_channel",
+			mcp.Description("Filter messages in a specific channel by its ID or name, or in the direct message with a user. Example: 'C1234567890', '#general' or '@username'."),
+		),
+		mcp.WithString("filter_users_from",
+			mcp.Description("Filter messages from a specific user by their ID or username. Example: 'U1234567890' or '@username'."),
+		),
+		mcp.WithString("filter_date_before",
+			mcp.Description("Filter messages sent before a specific date in format 'YYYY-MM-DD'. Example: '2023-10-01'."),
+		),
+		mcp.WithString("filter_date_after",
+			mcp.Description("Filter messages sent after a specific date in format 'YYYY-MM-DD'. Example: '2023-10-01'."),
+		),
+		mcp.WithString("filter_date_on",
+			mcp.Description("Filter messages sent on a specific date in format 'YYYY-MM-DD'. Example: '2023-10-01'."),
+		),
+		mcp.WithBoolean("filter_has_link",
+			mcp.DefaultBool(false),
+			mcp.Description("If true, only messages containing links are returned."),
+		),
+		mcp.WithBoolean("filter_has_reaction",
+			mcp.DefaultBool(false),
+			mcp.Description("If true, only messages with reactions are returned."),
+		),
+		mcp.WithBoolean("filter_threads_only",
+			mcp.DefaultBool(false),
+			mcp.Description("If true, only messages from threads are returned."),
+		),
+		mcp.WithString("sort",
+			mcp.DefaultString("score"),
+			mcp.Description("Type of sorting. Allowed values: 'score' - sort by relevance, 'timestamp' - sort by message time."),
+		),
+		mcp.WithString("sort_dir",
+			mcp.DefaultString("desc"),
+			mcp.Description("Sort direction. Allowed values: 'asc', 'desc'."),
+		),
+		mcp.WithNumber("limit",
+			mcp.DefaultNumber(20),
+			mcp.Description("The maximum number of items to return. Must be an integer between 1 and 100."),
+		),
+		mcp.WithString("cursor",
+			mcp.Description("Cursor for pagination. Use the value of the last row and column in the response as next_cursor field returned from the previous request."),
+		),
+		textModeParam,
+		formatParam,
+		workspaceParam,
+	), conversationsHandler.SearchMessagesHandler)
+
+	channelsHandler := handler.NewChannelsHandler(workspaces)
 
 	s.AddTool(mcp.NewTool("channels_list",
 		mcp.WithDescription("Get list of channels"),
@@ -50,7 +221,7 @@ func NewMCPServer(provider *provider.ApiProvider) *MCPServer {
 			mcp.Description("Comma-separated channel types. Allowed values: 'mpim', 'im', 'public_channel', 'private_channel'. Example: 'public_channel,private_channel,im'"),
 		),
 		mcp.WithString("sort",
-			mcp.Description("Type of sorting. Allowed values: 'popularity' - sort by number of members/participants in each channel."),
+			mcp.Description("Type of sorting. Allowed values: 'popularity' - sort by number of members/participants in each channel, 'name' - sort by name."),
 		),
 		mcp.WithNumber("limit",
 			mcp.DefaultNumber(100),
@@ -59,13 +230,194 @@ func NewMCPServer(provider *provider.ApiProvider) *MCPServer {
 		mcp.WithString("cursor",
 			mcp.Description("Cursor for pagination. Use the value of the last row and column in the response as next_cursor field returned from the previous request."),
 		),
+		formatParam,
+		workspaceParam,
 	), channelsHandler.ChannelsHandler)
 
+	s.AddTool(mcp.NewTool("bookmarks_list",
+		mcp.WithDescription("Get the bookmarks of a channel, the links shown below the channel header"),
+		channelParam,
+		formatParam,
+		workspaceParam,
+	), channelsHandler.BookmarksListHandler)
+
+	s.AddTool(mcp.NewTool("channel_info",
+		mcp.WithDescription("Get details of a channel: creator, creation time, archived/private/shared flags, member count, topic and purpose with who set them and when, and the time of the last message. Optionally followed by the channel's pinned items, bookmarks and earlier topic and purpose changes."),
+		channelParam,
+		mcp.WithBoolean("include_pins",
+			mcp.DefaultBool(false),
+			mcp.Description("If true, the channel's pinned items are returned after the channel details, in the same format as pins_list."),
+		),
+		mcp.WithBoolean("include_bookmarks",
+			mcp.DefaultBool(false),
+			mcp.Description("If true, the channel's bookmarks are returned after the channel details, in the same format as bookmarks_list."),
+		),
+		mcp.WithBoolean("include_topic_history",
+			mcp.DefaultBool(false),
+			mcp.Description("If true, earlier topic and purpose changes are returned after the channel details. They are found by scanning the channel history, newest first, up to the server's history message limit."),
+		),
+		formatParam,
+		workspaceParam,
+	), channelsHandler.ChannelInfoHandler)
+
+	s.AddTool(mcp.NewTool("channel_members",
+		mcp.WithDescription("Get the members of a channel with their names and titles, the last row/column in the response is used as 'cursor' parameter for pagination if not empty"),
+		channelParam,
+		mcp.WithNumber("limit",
+			mcp.DefaultNumber(100),
+			mcp.Description("The maximum number of items to return. Must be an integer between 1 and 1000."),
+		),
+		mcp.WithString("cursor",
+			mcp.Description("Cursor for pagination. Use the value of the last row and column in the response as next_cursor field returned from the previous request."),
+		),
+		formatParam,
+		workspaceParam,
+	), channelsHandler.ChannelMembersHandler)
+
+	usersHandler := handler.NewUsersHandler(workspaces)
+
+	s.AddTool(mcp.NewTool("users_list",
+		mcp.WithDescription("Get list of workspace users, the last row/column in the response is used as 'cursor' parameter for pagination if not empty"),
+		mcp.WithString("query",
+			mcp.Description("Case-insensitive substring to match against username, real name, display name, email or title. Example: 'jane'"),
+		),
+		mcp.WithBoolean("include_deleted",
+			mcp.DefaultBool(false),
+			mcp.Description("If true, deactivated users are included."),
+		),
+		mcp.WithBoolean("include_bots",
+			mcp.DefaultBool(false),
+			mcp.Description("If true, bot users are included."),
+		),
+		mcp.WithNumber("limit",
+			mcp.DefaultNumber(100),
+			mcp.Description("The maximum number of items to return. Must be an integer between 1 and 1000."),
+		),
+		mcp.WithString("cursor",
+			mcp.Description("Cursor for pagination. Use the value of the last row and column in the response as next_cursor field returned from the previous request."),
+		),
+		formatParam,
+		workspaceParam,
+	), usersHandler.UsersListHandler)
+
+	s.AddTool(mcp.NewTool("user_info",
+		mcp.WithDescription("Get information about one or more users by ID or username"),
+		mcp.WithString("user_id",
+			mcp.Required(),
+			mcp.Description("Comma-separated user IDs in format Uxxxxxxxxxx or usernames prefixed with '@'. Example: 'U1234567890,@jane'"),
+		),
+		formatParam,
+		workspaceParam,
+	), usersHandler.UserInfoHandler)
+
+	filesHandler := handler.NewFilesHandler(workspaces)
+
+	s.AddTool(mcp.NewTool("files_list",
+		mcp.WithDescription("Get list of files shared in the workspace, newest first, optionally filtered by channel, user, type and time. The last row/column in the response is used as 'cursor' parameter for pagination if not empty"),
+		mcp.WithString("channel_id",
+			mcp.Description("Only files shared in this channel. "+channelDescription),
+		),
+		mcp.WithString("user",
+			mcp.Description("Only files uploaded by this user, by ID or username prefixed with '@'. Example: 'U1234567890' or '@jane'"),
+		),
+		mcp.WithString("types",
+			mcp.Description("Comma-separated file types. Allowed values: 'all', 'spaces', 'snippets', 'images', 'gdocs', 'zips', 'pdfs'. Example: 'images,pdfs'"),
+		),
+		mcp.WithString("oldest",
+			mcp.Description("Only files created after this time. Accepts a date (2026-09-01), a date and time (2026-09-01 09:30), an RFC3339 timestamp (2026-09-01T09:30:00Z), a Slack timestamp (1700000000.123456) or a duration before now (30m, 2h, 3d, 1w). Dates and times without offset use the server timezone."),
+		),
+		mcp.WithString("latest",
+			mcp.Description("Only files created before this time, in the same formats as 'oldest'. A date alone includes that whole day."),
+		),
+		mcp.WithNumber("limit",
+			mcp.DefaultNumber(20),
+			mcp.Description("The maximum number of items to return. Must be an integer between 1 and 100."),
+		),
+		mcp.WithString("cursor",
+			mcp.Description("Cursor for pagination. Use the value of the last row and column in the response as next_cursor field returned from the previous request."),
+		),
+		formatParam,
+		workspaceParam,
+	), filesHandler.FilesListHandler)
+
+	s.AddTool(mcp.NewTool("file_get",
+		mcp.WithDescription("Get a file's metadata and content by file ID, as listed by files_list or in the files column of messages. Text, code and CSV files are returned as text, PDFs as their extracted text, and other files such as images as an embedded resource. A PDF without a text layer, such as a scan, is returned as Slack's text preview if it has one, otherwise as an embedded resource. Files larger than the server's size limit are returned without content."),
+		mcp.WithString("file_id",
+			mcp.Required(),
+			mcp.Description("File ID in format Fxxxxxxxxxx"),
+		),
+		formatParam,
+		workspaceParam,
+	), filesHandler.FileGetHandler)
+
+	s.AddTool(mcp.NewTool("files_upload",
+		mcp.WithDescription("Upload content as a file to a channel or thread, e.g. a report or log that is too long for a message. Disabled unless SLACK_MCP_ADD_MESSAGE_TOOL is set."),
+		channelParam,
+		mcp.WithString("content",
+			mcp.Required(),
+			mcp.Description("Content of the file, as text or base64 encoded according to content_encoding."),
+		),
+		mcp.WithString("content_encoding",
+			mcp.DefaultString("text"),
+			mcp.Description("Encoding of content. Allowed values: 'text' - the content is the file's text, 'base64' - the content is the base64 encoded file, for binary files."),
+		),
+		mcp.WithString("filename",
+			mcp.Required(),
+			mcp.Description("Name of the file including its extension, which Slack uses to detect the file type. Example: 'report.md'"),
+		),
+		mcp.WithString("title",
+			mcp.Description("Title of the file. Defaults to the filename."),
+		),
+		mcp.WithString("thread_ts",
+			mcp.Description("Timestamp in format 1234567890.123456 of the thread's parent message. Optional, if provided the file is shared in the thread."),
+		),
+		mcp.WithString("initial_comment",
+			mcp.Description("Message text posted together with the file."),
+		),
+		formatParam,
+		workspaceParam,
+	), filesHandler.FilesUploadHandler)
+
+	healthHandler := handler.NewHealthHandler(workspaces)
+
+	s.AddTool(mcp.NewTool("server_health",
+		mcp.WithDescription("Get the state of the connection to Slack: whether the server authenticated successfully, the last boot error if any, and the number of cached users"),
+		formatParam,
+		workspaceParam,
+	), healthHandler.HealthHandler)
+
+	workspacesHandler := handler.NewWorkspacesHandler(workspaces)
+
+	s.AddTool(mcp.NewTool("workspaces_list",
+		mcp.WithDescription("Get list of configured Slack workspaces. Pass the name as 'workspace' parameter to other tools to read from that workspace."),
+		formatParam,
+	), workspacesHandler.WorkspacesListHandler)
+
 	return &MCPServer{
 		server: s,
 	}
 }
 
+// toolErrorMiddleware reports errors returned by tool handlers as tool
+// results with isError set, so that the client and the model see the
+// actual message (e.g. expired credentials or a missing scope) instead of
+// a generic failure.
+func toolErrorMiddleware(workspaces *provider.Workspaces) server.ToolHandlerMiddleware {
+	return func(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+		return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			result, err := next(ctx, request)
+			if err != nil {
+				if apiProvider, perr := workspaces.Provider(request.GetString("workspace", "")); perr == nil {
+					err = apiProvider.DescribeError(err)
+				}
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			return result, nil
+		}
+	}
+}
+
 func (s *MCPServer) ServeSSE(addr string) *server.SSEServer {
 	return server.NewSSEServer(s.server,
 		server.WithBaseURL(fmt.Sprintf("http://%s", addr)),
@@ -0,0 +1,47 @@
+package text
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	mdCodeFenceRe  = regexp.MustCompile("(?s)```.*?```")
+	mdInlineCodeRe = regexp.MustCompile("`[^`\n]+`")
+	mdLinkRe       = regexp.MustCompile(`\[([^\]]+)\]\((https?://[^)\s]+)\)`)
+	mdBoldRe       = regexp.MustCompile(`\*\*(.+?)\*\*|__(.+?)__`)
+	mdItalicRe     = regexp.MustCompile(`(^|[^*\w])\*([^*\s](?:[^*]*[^*\s])?)\*([^*\w]|$)`)
+	mdStrikeRe     = regexp.MustCompile(`~~(.+?)~~`)
+	mdHeadingRe    = regexp.MustCompile(`(?m)^#{1,6}\s+(.+?)\s*#*$`)
+	mdBulletRe     = regexp.MustCompile(`(?m)^(\s*)[-*+]\s+`)
+)
+
+// boldMarker temporarily replaces converted bold markers so that the
+// italic pass does not treat them as single asterisks.
+const boldMarker = "\x00"
+
+// MarkdownToMrkdwn converts common Markdown constructs into Slack's mrkdwn
+// dialect. Code blocks and inline code are left untouched.
+func MarkdownToMrkdwn(s string) string {
+	var code []string
+	protect := func(m string) string {
+		code = append(code, m)
+		return "\x01" + strings.Repeat("\x02", len(code)) + "\x01"
+	}
+	s = mdCodeFenceRe.ReplaceAllStringFunc(s, protect)
+	s = mdInlineCodeRe.ReplaceAllStringFunc(s, protect)
+
+	s = mdLinkRe.ReplaceAllString(s, "<$2|$1>")
+	s = mdHeadingRe.ReplaceAllString(s, boldMarker+"$1"+boldMarker)
+	s = mdBulletRe.ReplaceAllString(s, "$1• ")
+	s = mdBoldRe.ReplaceAllString(s, boldMarker+"$1$2"+boldMarker)
+	s = mdItalicRe.ReplaceAllString(s, "${1}_${2}_${3}")
+	s = mdStrikeRe.ReplaceAllString(s, "~$1~")
+	s = strings.ReplaceAll(s, boldMarker, "*")
+
+	for i := len(code) - 1; i >= 0; i-- {
+		s = strings.Replace(s, "\x01"+strings.Repeat("\x02", i+1)+"\x01", code[i], 1)
+	}
+
+	return s
+}
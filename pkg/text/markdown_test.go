@@ -0,0 +1,75 @@
+package text
+
+import "testing"
+
+func TestMarkdownToMrkdwn(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{
+			name:  "Plain text is unchanged",
+			input: "hello world",
+			want:  "hello world",
+		},
+		{
+			name:  "Bold with asterisks",
+			input: "this is **important**",
+			want:  "this is *important*",
+		},
+		{
+			name:  "Bold with underscores",
+			input: "this is __important__",
+			want:  "this is *important*",
+		},
+		{
+			name:  "Italic with single asterisk",
+			input: "this is *subtle* text",
+			want:  "this is _subtle_ text",
+		},
+		{
+			name:  "Bold and italic together",
+			input: "**bold** and *italic*",
+			want:  "*bold* and _italic_",
+		},
+		{
+			name:  "Strikethrough",
+			input: "~~old~~ new",
+			want:  "~old~ new",
+		},
+		{
+			name:  "Link",
+			input: "see [the docs](https://example.com/docs)",
+			want:  "see <https://example.com/docs|the docs>",
+		},
+		{
+			name:  "Heading",
+			input: "## Summary\ntext",
+			want:  "*Summary*\ntext",
+		},
+		{
+			name:  "Bullet list",
+			input: "- one\n* two\n  + nested",
+			want:  "• one\n• two\n  • nested",
+		},
+		{
+			name:  "Inline code is preserved",
+			input: "run `**not bold**` now",
+			want:  "run `**not bold**` now",
+		},
+		{
+			name:  "Code fence is preserved",
+			input: "```\n# not a heading\n- not a bullet\n```",
+			want:  "```\n# not a heading\n- not a bullet\n```",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := MarkdownToMrkdwn(tt.input); got != tt.want {
+				t.Errorf("MarkdownToMrkdwn() = '%s', want '%s'", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package text
+
+import (
+	"regexp"
+	"strings"
+)
+
+// MentionResolver looks up display names for the IDs referenced by Slack
+// mention tokens. Implementations return false when the ID is unknown.
+type MentionResolver interface {
+	UserName(id string) (string, bool)
+	ChannelName(id string) (string, bool)
+}
+
+var mentionRe = regexp.MustCompile(`<([^<>\s][^<>]*)>`)
+
+// ResolveMentions rewrites Slack's angle-bracket tokens into plain text:
+//   - <@U123> or <@U123|name> becomes @username,
+//   - <#C123> or <#C123|name> becomes #channel,
+//   - <!subteam^S123|@group> becomes @group,
+//   - <!here>, <!channel>, <!everyone> become @here, @channel, @everyone,
+//   - <https://x|label> becomes "label (https://x)" and <https://x> becomes the URL.
+//
+// Tokens that cannot be resolved keep their ID so that no information is lost.
+func ResolveMentions(s string, r MentionResolver) string {
+	return mentionRe.ReplaceAllStringFunc(s, func(token string) string {
+		inner := token[1 : len(token)-1]
+		ref, label, _ := strings.Cut(inner, "|")
+
+		switch {
+		case strings.HasPrefix(ref, "@"):
+			id := ref[1:]
+			if r != nil {
+				if name, ok := r.UserName(id); ok {
+					return "@" + name
+				}
+			}
+			if label != "" {
+				return "@" + strings.TrimPrefix(label, "@")
+			}
+			return "@" + id
+		case strings.HasPrefix(ref, "#"):
+			id := ref[1:]
+			if label != "" {
+				return "#" + label
+			}
+			if r != nil {
+				if name, ok := r.ChannelName(id); ok {
+					return "#" + name
+				}
+			}
+			return "#" + id
+		case strings.HasPrefix(ref, "!subteam^"):
+			if label != "" {
+				return "@" + strings.TrimPrefix(label, "@")
+			}
+			return "@" + strings.TrimPrefix(ref, "!subteam^")
+		case strings.HasPrefix(ref, "!"):
+			if label != "" {
+				return label
+			}
+			name, _, _ := strings.Cut(ref[1:], "^")
+			return "@" + name
+		case strings.HasPrefix(ref, "mailto:"):
+			if label != "" {
+				return label
+			}
+			return strings.TrimPrefix(ref, "mailto:")
+		case strings.Contains(ref, "://"):
+			if label != "" && label != ref {
+				return label + " (" + ref + ")"
+			}
+			return ref
+		default:
+			return token
+		}
+	})
+}
@@ -0,0 +1,98 @@
+package text
+
+import "testing"
+
+type staticResolver struct {
+	users    map[string]string
+	channels map[string]string
+}
+
+func (r staticResolver) UserName(id string) (string, bool) {
+	name, ok := r.users[id]
+	return name, ok
+}
+
+func (r staticResolver) ChannelName(id string) (string, bool) {
+	name, ok := r.channels[id]
+	return name, ok
+}
+
+func TestResolveMentions(t *testing.T) {
+	resolver := staticResolver{
+		users:    map[string]string{"U123": "jane"},
+		channels: map[string]string{"C123": "general"},
+	}
+
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{
+			name:  "Known user",
+			input: "ping <@U123> please",
+			want:  "ping @jane please",
+		},
+		{
+			name:  "Unknown user keeps ID",
+			input: "ping <@U999>",
+			want:  "ping @U999",
+		},
+		{
+			name:  "Channel with label",
+			input: "see <#C999|random>",
+			want:  "see #random",
+		},
+		{
+			name:  "Channel without label",
+			input: "see <#C123>",
+			want:  "see #general",
+		},
+		{
+			name:  "Channel with empty label",
+			input: "see <#C123|>",
+			want:  "see #general",
+		},
+		{
+			name:  "User group",
+			input: "cc <!subteam^S123|@oncall>",
+			want:  "cc @oncall",
+		},
+		{
+			name:  "Special mentions",
+			input: "<!here> and <!channel>",
+			want:  "@here and @channel",
+		},
+		{
+			name:  "Link with label",
+			input: "read <https://example.com/doc|the doc>",
+			want:  "read the doc (https://example.com/doc)",
+		},
+		{
+			name:  "Bare link",
+			input: "read <https://example.com/doc>",
+			want:  "read https://example.com/doc",
+		},
+		{
+			name:  "Mailto link",
+			input: "mail <mailto:jane@example.com|jane@example.com>",
+			want:  "mail jane@example.com",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ResolveMentions(tt.input, resolver); got != tt.want {
+				t.Errorf("ResolveMentions() = '%s', want '%s'", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveMentionsNilResolver(t *testing.T) {
+	input := "<@U123|jane> in <#C123>"
+	want := "@jane in #C123"
+	if got := ResolveMentions(input, nil); got != want {
+		t.Errorf("ResolveMentions() = '%s', want '%s'", got, want)
+	}
+}
@@ -1,17 +1,115 @@
 package text
 
 import (
+	"fmt"
+	"html"
+	"regexp"
 	"strings"
 
 	"github.com/bbalet/stopwords"
 )
 
+// Mode selects how much a message text is transformed before it is
+// returned to the client.
+type Mode string
+
+const (
+	// ModeRaw returns the text exactly as received from Slack.
+	ModeRaw Mode = "raw"
+	// ModeNormalized unescapes Slack's HTML entities and collapses
+	// redundant whitespace, keeping words and casing intact.
+	ModeNormalized Mode = "normalized"
+	// ModeCompact lowercases the text and strips stopwords to save tokens.
+	ModeCompact Mode = "compact"
+)
+
+var AllModes = []Mode{ModeRaw, ModeNormalized, ModeCompact}
+
+// Options controls ProcessTextWithOptions.
+type Options struct {
+	Mode Mode
+	// Language is the ISO 639-1 code of the stopword list used by
+	// ModeCompact, one of Languages. Use ParseLanguage to validate it; an
+	// unsupported code removes no stopwords.
+	Language string
+}
+
+var DefaultOptions = Options{Mode: ModeCompact, Language: "en"}
+
+// Languages are the codes of the stopword lists available to ModeCompact.
+var Languages = []string{
+	"ar", "bg", "cs", "da", "de", "el", "en", "es", "fa", "fr", "fi", "hu", "id", "it",
+	"ja", "km", "lv", "nl", "no", "pl", "pt", "ro", "ru", "sk", "sv", "th", "tr",
+}
+
+var (
+	horizontalSpaceRe = regexp.MustCompile(`[ \t\f\v\x{00a0}]+`)
+	blankLinesRe      = regexp.MustCompile(`\n{3,}`)
+)
+
+// ParseMode validates a mode name. An empty string yields ModeCompact.
+func ParseMode(s string) (Mode, error) {
+	if s == "" {
+		return ModeCompact, nil
+	}
+	for _, m := range AllModes {
+		if Mode(s) == m {
+			return m, nil
+		}
+	}
+
+	return "", fmt.Errorf("invalid text mode %q: allowed values are 'raw', 'normalized' and 'compact'", s)
+}
+
+// ParseLanguage validates a language code against Languages. Region
+// subtags are dropped, so "en-US" and "pt_BR" yield "en" and "pt".
+func ParseLanguage(s string) (string, error) {
+	code, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(s)), "-")
+	code, _, _ = strings.Cut(code, "_")
+	for _, l := range Languages {
+		if code == l {
+			return l, nil
+		}
+	}
+
+	return "", fmt.Errorf("unsupported language %q: allowed values are %s", s, strings.Join(Languages, ", "))
+}
+
 func ProcessText(s string) string {
-	s = stopwordsFilter(s)
-	s = strings.TrimSpace(s)
-	return s
+	return ProcessTextWithOptions(s, DefaultOptions)
+}
+
+func ProcessTextWithOptions(s string, opts Options) string {
+	switch opts.Mode {
+	case ModeRaw:
+		return s
+	case ModeNormalized:
+		return normalize(s)
+	default:
+		s = stopwordsFilter(s, opts.Language)
+		s = strings.TrimSpace(s)
+		return s
+	}
+}
+
+func normalize(s string) string {
+	s = html.UnescapeString(s)
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSpace(horizontalSpaceRe.ReplaceAllString(line, " "))
+	}
+	s = strings.Join(lines, "\n")
+	s = blankLinesRe.ReplaceAllString(s, "\n\n")
+
+	return strings.TrimSpace(s)
 }
 
-func stopwordsFilter(s string) string {
-	return stopwords.CleanString(s, "en", true)
+func stopwordsFilter(s string, language string) string {
+	if language == "" {
+		language = DefaultOptions.Language
+	}
+
+	return stopwords.CleanString(s, language, true)
 }
@@ -58,3 +58,86 @@ func TestProcessText(t *testing.T) {
 		})
 	}
 }
+
+func TestProcessTextWithOptions(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		opts  Options
+		want  string
+	}{
+		{
+			name:  "Raw keeps text untouched",
+			input: "  This is NOT &amp; a test  ",
+			opts:  Options{Mode: ModeRaw},
+			want:  "  This is NOT &amp; a test  ",
+		},
+		{
+			name:  "Normalized keeps words and casing",
+			input: "  This   is NOT &lt;fine&gt;\t here ",
+			opts:  Options{Mode: ModeNormalized},
+			want:  "This is NOT <fine> here",
+		},
+		{
+			name:  "Normalized collapses blank lines",
+			input: "first\r\n\r\n\r\n\r\nsecond  \nthird",
+			opts:  Options{Mode: ModeNormalized},
+			want:  "first\n\nsecond\nthird",
+		},
+		{
+			name:  "Compact with English stopwords",
+			input: "This is a test string with some stopwords",
+			opts:  Options{Mode: ModeCompact, Language: "en"},
+			want:  "test string stopwords",
+		},
+		{
+			name:  "Compact with German stopwords",
+			input: "Das ist ein Test",
+			opts:  Options{Mode: ModeCompact, Language: "de"},
+			want:  "test",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ProcessTextWithOptions(tt.input, tt.opts); got != tt.want {
+				t.Errorf("ProcessTextWithOptions() = '%s', want '%s'", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseMode(t *testing.T) {
+	for _, s := range []string{"raw", "normalized", "compact"} {
+		if m, err := ParseMode(s); err != nil || string(m) != s {
+			t.Errorf("ParseMode(%q) = %q, %v", s, m, err)
+		}
+	}
+	if m, err := ParseMode(""); err != nil || m != ModeCompact {
+		t.Errorf("ParseMode(\"\") = %q, %v, want compact", m, err)
+	}
+	if _, err := ParseMode("verbose"); err == nil {
+		t.Errorf("ParseMode(\"verbose\") expected error")
+	}
+}
+
+func TestParseLanguage(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{"de", "de", false},
+		{"en-US", "en", false},
+		{"pt_BR", "pt", false},
+		{" FR ", "fr", false},
+		{"xx", "", true},
+		{"", "", true},
+	}
+	for _, tt := range tests {
+		got, err := ParseLanguage(tt.in)
+		if got != tt.want || (err != nil) != tt.wantErr {
+			t.Errorf("ParseLanguage(%q) = %q, %v", tt.in, got, err)
+		}
+	}
+}
@@ -0,0 +1,347 @@
+package transport
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"math/rand"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	defaultMaxRetries = 3
+	defaultMaxWait    = time.Minute
+	baseBackoff       = 500 * time.Millisecond
+)
+
+// Slack rate limit tiers in requests per minute, see
+// https://api.slack.com/apis/rate-limits.
+const (
+	tier2 = 20
+	tier3 = 50
+	tier4 = 100
+)
+
+// methodTiers maps Slack Web API methods to their rate limit tier. Methods
+// that are not listed use tier 3.
+var methodTiers = map[string]int{
+	"conversations.list":    tier2,
+	"conversations.members": tier4,
+	"search.messages":       tier2,
+	"users.list":            tier2,
+	"pins.list":             tier2,
+	"files.list":            tier3,
+	"users.info":            tier4,
+	"users.conversations":   tier3,
+	"auth.test":             tier4,
+	"chat.postMessage":      60,
+	"reactions.add":         tier3,
+	"reactions.remove":      tier3,
+}
+
+// writeMethods are not retried on server or network errors because Slack
+// may already have applied them. Rate limited requests are always retried
+// as Slack rejects them before processing.
+var writeMethods = map[string]bool{
+	"chat.postMessage":             true,
+	"chat.update":                  true,
+	"chat.delete":                  true,
+	"reactions.add":                true,
+	"reactions.remove":             true,
+	"files.upload":                 true,
+	"files.getUploadURLExternal":   true,
+	"files.completeUploadExternal": true,
+}
+
+// Stats are counters of a RetryTransport.
+type Stats struct {
+	Requests    int64
+	Retries     int64
+	RateLimited int64
+	BudgetWaits int64
+}
+
+// RetryTransport retries Slack API requests that were rate limited or
+// failed transiently. It honors the Retry-After header of 429 responses,
+// retries 5xx responses and network errors with jittered exponential
+// backoff, and spaces requests per API method so that each method stays
+// within the budget of its Slack rate limit tier.
+type RetryTransport struct {
+	roundTripper http.RoundTripper
+	maxRetries   int
+	maxWait      time.Duration
+	budgets      *budgets
+
+	// sleep waits for d or until ctx is done, replaced in tests.
+	sleep func(ctx context.Context, d time.Duration) error
+
+	requests    atomic.Int64
+	retries     atomic.Int64
+	rateLimited atomic.Int64
+	budgetWaits atomic.Int64
+}
+
+// NewRetry wraps roundTripper. A negative maxRetries uses the default of 3
+// retries; when tierBudgets is false requests are only throttled by the
+// Retry-After responses of Slack itself.
+func NewRetry(roundTripper http.RoundTripper, maxRetries int, tierBudgets bool) *RetryTransport {
+	if maxRetries < 0 {
+		maxRetries = defaultMaxRetries
+	}
+
+	t := &RetryTransport{
+		roundTripper: roundTripper,
+		maxRetries:   maxRetries,
+		maxWait:      defaultMaxWait,
+		sleep:        sleepContext,
+	}
+	if tierBudgets {
+		t.budgets = newBudgets()
+	}
+
+	return t
+}
+
+// RoundTrip implements the RoundTripper interface.
+func (t *RetryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	method := path.Base(req.URL.Path)
+	ctx := req.Context()
+	// Only Web API methods have a rate limit tier. Other requests, such as
+	// file downloads and uploads, are sent without a budget.
+	budgets := t.budgets
+	if !isAPIMethod(req.URL.Path) {
+		budgets = nil
+	}
+	// Requests whose body cannot be read again are sent once, and their
+	// response is returned as it is.
+	replayable := req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+
+	t.requests.Add(1)
+
+	for attempt := 0; ; attempt++ {
+		if budgets != nil {
+			if wait := budgets.reserve(method, time.Now()); wait > 0 {
+				t.budgetWaits.Add(1)
+				log.Printf("Throttling %s for %s to stay within its rate limit tier", method, wait.Round(time.Millisecond))
+				if err := t.sleep(ctx, wait); err != nil {
+					return nil, err
+				}
+			}
+		}
+
+		attemptReq, err := rewindRequest(req, attempt)
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := t.roundTripper.RoundTrip(attemptReq)
+
+		wait, retry := t.shouldRetry(method, budgets, attempt, replayable, resp, err)
+		if !retry {
+			return resp, err
+		}
+
+		if resp != nil {
+			// Drain so that the connection can be reused.
+			io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+			resp.Body.Close()
+		}
+
+		t.retries.Add(1)
+		if err := t.sleep(ctx, wait); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// Stats returns a snapshot of the transport counters.
+func (t *RetryTransport) Stats() Stats {
+	return Stats{
+		Requests:    t.requests.Load(),
+		Retries:     t.retries.Load(),
+		RateLimited: t.rateLimited.Load(),
+		BudgetWaits: t.budgetWaits.Load(),
+	}
+}
+
+func (t *RetryTransport) shouldRetry(method string, budgets *budgets, attempt int, replayable bool, resp *http.Response, err error) (time.Duration, bool) {
+	if attempt >= t.maxRetries || !replayable {
+		return 0, false
+	}
+
+	if err != nil {
+		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) || writeMethods[method] {
+			return 0, false
+		}
+		wait := backoff(attempt)
+		log.Printf("Request to %s failed: %v; retrying in %s", method, err, wait.Round(time.Millisecond))
+		return wait, true
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests:
+		t.rateLimited.Add(1)
+		wait := retryAfter(resp, backoff(attempt))
+		if wait > t.maxWait {
+			log.Printf("Rate limited on %s, Retry-After %s exceeds %s; giving up", method, wait, t.maxWait)
+			return 0, false
+		}
+		if budgets != nil {
+			budgets.pause(method, time.Now().Add(wait))
+		}
+		log.Printf("Rate limited on %s; retrying in %s", method, wait)
+		return wait, true
+	case resp.StatusCode >= 500 && !writeMethods[method]:
+		wait := retryAfter(resp, backoff(attempt))
+		if wait > t.maxWait {
+			return 0, false
+		}
+		log.Printf("Request to %s failed with status %d; retrying in %s", method, resp.StatusCode, wait.Round(time.Millisecond))
+		return wait, true
+	default:
+		return 0, false
+	}
+}
+
+// isAPIMethod reports whether p is the path of a Web API method, such as
+// "/api/conversations.history".
+func isAPIMethod(p string) bool {
+	dir, method := path.Split(p)
+	return method != "" && strings.HasSuffix(dir, "/api/")
+}
+
+// rewindRequest returns the request to send for the given attempt. Retries
+// need a fresh body, so only requests with GetBody are retried.
+func rewindRequest(req *http.Request, attempt int) (*http.Request, error) {
+	if attempt == 0 || req.Body == nil || req.Body == http.NoBody {
+		return req, nil
+	}
+	if req.GetBody == nil {
+		return nil, errors.New("request body cannot be replayed for retry")
+	}
+
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, err
+	}
+
+	clonedReq := req.Clone(req.Context())
+	clonedReq.Body = body
+
+	return clonedReq, nil
+}
+
+// retryAfter parses the Retry-After header in seconds.
+func retryAfter(resp *http.Response, fallback time.Duration) time.Duration {
+	seconds, err := strconv.Atoi(resp.Header.Get("Retry-After"))
+	if err != nil || seconds < 0 {
+		return fallback
+	}
+
+	return time.Duration(seconds) * time.Second
+}
+
+// backoff returns an exponential delay with full jitter.
+func backoff(attempt int) time.Duration {
+	d := baseBackoff << attempt
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// budgets spaces requests per method as a token bucket refilled at the
+// rate of the method's tier, with a burst of half a minute's worth.
+type budgets struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+}
+
+type bucket struct {
+	tokens   float64
+	updated  time.Time
+	perSec   float64
+	capacity float64
+	paused   time.Time
+}
+
+func newBudgets() *budgets {
+	return &budgets{buckets: make(map[string]*bucket)}
+}
+
+// reserve takes a token for method and returns how long the caller must
+// wait before sending the request.
+func (b *budgets) reserve(method string, now time.Time) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	bk := b.bucket(method, now)
+
+	bk.tokens += now.Sub(bk.updated).Seconds() * bk.perSec
+	if bk.tokens > bk.capacity {
+		bk.tokens = bk.capacity
+	}
+	bk.updated = now
+	bk.tokens--
+
+	var wait time.Duration
+	if bk.tokens < 0 {
+		wait = time.Duration(-bk.tokens / bk.perSec * float64(time.Second))
+	}
+	if pause := bk.paused.Sub(now); pause > wait {
+		wait = pause
+	}
+
+	return wait
+}
+
+// pause holds back every request to method until the given time, after
+// Slack answered with Retry-After.
+func (b *budgets) pause(method string, until time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	bk := b.bucket(method, time.Now())
+	if until.After(bk.paused) {
+		bk.paused = until
+	}
+}
+
+func (b *budgets) bucket(method string, now time.Time) *bucket {
+	bk, ok := b.buckets[method]
+	if !ok {
+		perMinute, ok := methodTiers[method]
+		if !ok {
+			perMinute = tier3
+		}
+		capacity := float64(perMinute) / 2
+		if capacity < 1 {
+			capacity = 1
+		}
+		bk = &bucket{
+			tokens:   capacity,
+			updated:  now,
+			perSec:   float64(perMinute) / 60,
+			capacity: capacity,
+		}
+		b.buckets[method] = bk
+	}
+
+	return bk
+}
@@ -0,0 +1,200 @@
+package transport
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newTestRetry returns a transport that records its sleeps instead of
+// waiting.
+func newTestRetry(maxRetries int, tierBudgets bool) (*RetryTransport, *[]time.Duration) {
+	var slept []time.Duration
+	rt := NewRetry(http.DefaultTransport, maxRetries, tierBudgets)
+	rt.sleep = func(_ context.Context, d time.Duration) error {
+		slept = append(slept, d)
+		return nil
+	}
+
+	return rt, &slept
+}
+
+// statusSequence serves the given statuses in order, then 200.
+func statusSequence(t *testing.T, statuses ...int) (*httptest.Server, *atomic.Int64) {
+	t.Helper()
+
+	calls := new(atomic.Int64)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if r.Method == http.MethodPost && string(body) != "token=x" {
+			t.Errorf("unexpected body %q", body)
+		}
+
+		n := int(calls.Add(1)) - 1
+		if n < len(statuses) {
+			if statuses[n] == http.StatusTooManyRequests {
+				w.Header().Set("Retry-After", "2")
+			}
+			w.WriteHeader(statuses[n])
+			return
+		}
+		w.Write([]byte(`{"ok":true}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv, calls
+}
+
+func post(t *testing.T, rt http.RoundTripper, url string) *http.Response {
+	t.Helper()
+
+	req, err := http.NewRequest(http.MethodPost, url, strings.NewReader("token=x"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := rt.RoundTrip(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	return resp
+}
+
+func TestRetryTransport(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		statuses   []int
+		wantStatus int
+		wantCalls  int64
+		wantSleeps []time.Duration
+	}{
+		{"rate limited honors Retry-After", "conversations.history", []int{429}, 200, 2, []time.Duration{2 * time.Second}},
+		{"server error is retried", "conversations.history", []int{503, 502}, 200, 3, nil},
+		{"client error is not retried", "conversations.history", []int{400}, 400, 1, nil},
+		{"retries are bounded", "conversations.history", []int{500, 500, 500, 500, 500}, 500, 4, nil},
+		{"write is retried when rate limited", "chat.postMessage", []int{429}, 200, 2, []time.Duration{2 * time.Second}},
+		{"write is not retried on server error", "chat.postMessage", []int{500}, 500, 1, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, calls := statusSequence(t, tt.statuses...)
+			rt, slept := newTestRetry(3, false)
+
+			resp := post(t, rt, srv.URL+"/api/"+tt.method)
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+			if got := calls.Load(); got != tt.wantCalls {
+				t.Errorf("calls = %d, want %d", got, tt.wantCalls)
+			}
+			if int64(len(*slept)) != tt.wantCalls-1 {
+				t.Errorf("sleeps = %v, want %d", *slept, tt.wantCalls-1)
+			}
+			for i, want := range tt.wantSleeps {
+				if (*slept)[i] != want {
+					t.Errorf("sleep %d = %s, want %s", i, (*slept)[i], want)
+				}
+			}
+		})
+	}
+}
+
+func TestRetryTransportRetryAfterBeyondCap(t *testing.T) {
+	calls := new(atomic.Int64)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Header().Set("Retry-After", "3600")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer srv.Close()
+
+	rt, slept := newTestRetry(3, false)
+	resp := post(t, rt, srv.URL+"/api/users.list")
+	if resp.StatusCode != http.StatusTooManyRequests {
+		t.Errorf("status = %d, want 429", resp.StatusCode)
+	}
+	if calls.Load() != 1 || len(*slept) != 0 {
+		t.Errorf("calls = %d, sleeps = %v; want a single call without waiting", calls.Load(), *slept)
+	}
+
+	stats := rt.Stats()
+	if stats.Requests != 1 || stats.RateLimited != 1 || stats.Retries != 0 {
+		t.Errorf("stats = %+v", stats)
+	}
+}
+
+func TestRetryTransportBodyNotReplayable(t *testing.T) {
+	srv, calls := statusSequence(t, http.StatusServiceUnavailable)
+	rt, slept := newTestRetry(3, false)
+
+	// A body without GetBody cannot be sent again.
+	req, err := http.NewRequest(http.MethodPost, srv.URL+"/api/conversations.history", io.MultiReader(strings.NewReader("token=x")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := rt.RoundTrip(req)
+	if err != nil {
+		t.Fatalf("RoundTrip() error = %v, want the original response", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("status = %d, want 503", resp.StatusCode)
+	}
+	if calls.Load() != 1 || len(*slept) != 0 {
+		t.Errorf("calls = %d, sleeps = %v; want a single call without waiting", calls.Load(), *slept)
+	}
+}
+
+func TestRetryTransportBudgetsOnlyAPIMethods(t *testing.T) {
+	srv, _ := statusSequence(t)
+	rt, slept := newTestRetry(3, true)
+
+	// File downloads are not Web API methods and have no tier budget.
+	for i := 0; i < 30; i++ {
+		post(t, rt, fmt.Sprintf("%s/files-pri/T0123456789-F%d/download/report.pdf", srv.URL, i))
+	}
+	if len(*slept) != 0 || len(rt.budgets.buckets) != 0 {
+		t.Errorf("file downloads were budgeted: sleeps = %v, buckets = %d", *slept, len(rt.budgets.buckets))
+	}
+
+	// users.list is tier 2 with a burst of 10.
+	for i := 0; i < 11; i++ {
+		post(t, rt, srv.URL+"/api/users.list")
+	}
+	if len(*slept) != 1 || rt.Stats().BudgetWaits != 1 {
+		t.Errorf("sleeps = %v, want a single wait for users.list", *slept)
+	}
+}
+
+func TestBudgets(t *testing.T) {
+	b := newBudgets()
+	now := time.Now()
+
+	// users.list is tier 2: a burst of 10, then one request every 3s.
+	for i := 0; i < 10; i++ {
+		if wait := b.reserve("users.list", now); wait != 0 {
+			t.Fatalf("request %d waited %s within the burst", i, wait)
+		}
+	}
+	if wait := b.reserve("users.list", now); wait != 3*time.Second {
+		t.Errorf("wait after burst = %s, want 3s", wait)
+	}
+	if wait := b.reserve("users.info", now); wait != 0 {
+		t.Errorf("other methods must have their own budget, waited %s", wait)
+	}
+
+	b.pause("conversations.history", now.Add(time.Minute))
+	if wait := b.reserve("conversations.history", now); wait < 59*time.Second {
+		t.Errorf("wait while paused = %s, want about 1m", wait)
+	}
+}